@@ -0,0 +1,163 @@
+package nora
+
+import (
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/vmath"
+)
+
+// DefaultBatchVertices is the default number of vertices after which a batch is flushed.
+// Allows drawing with 16bit indices.
+const DefaultBatchVertices = 0xFFFF
+
+// Batch accumulates the geometry of multiple objects that share the same material and draws it with as few draw calls as possible.
+// It can be used as an alternative to having one Mesh per sprite or shape.
+//
+// Vertex positions are transformed on the CPU, using the top of the render state's transform stack.
+// The accumulated geometry is drawn (flushed) automatically if the material, primitive type or vertex attributes change,
+// if anything else is drawn, if the camera or viewport changes, and at the end of the frame or nested rendering.
+// Batching is only possible for float32 geometry with an interleaved buffer layout.
+type Batch struct {
+	mesh Mesh
+
+	// PositionAttribute is the name of the vertex attribute that is transformed on the CPU.
+	PositionAttribute string
+	// MaxVertices is the number of vertices after which the batch is flushed.
+	MaxVertices int
+
+	material         *Material
+	primitiveType    PrimitiveType
+	vertexAttributes []string
+	vertexSize       int // in floats
+	posOffset        int // offset of the position attribute in floats; -1 if there is none
+	posComponents    int
+
+	vertexCount int
+	vertices    []float32
+	indices     []uint32
+}
+
+// NewBatch creates a new, empty batch.
+func NewBatch() *Batch {
+	b := &Batch{
+		mesh:              *NewMesh(nil),
+		PositionAttribute: "position",
+		MaxVertices:       DefaultBatchVertices,
+	}
+	b.mesh.SetUsage(StreamBuffer)
+	return b
+}
+
+// Destroy deletes all resources associated with the batch.
+func (b *Batch) Destroy() {
+	b.mesh.Destroy()
+}
+
+// Add appends the geometry to the batch, transformed by the transform stack's top.
+// If the batch contains geometry that is incompatible, it is drawn first.
+// Supported primitive types are points, lines and triangles.
+func (b *Batch) Add(renderState *RenderState, material *Material, geom *Geometry) {
+	if geom.vertexCount == 0 {
+		return
+	}
+	if !assert.True(geom.vertexFormat == nil, "Batching requires float32 vertex data") ||
+		!assert.True(geom.bufferLayout == InterleavedBuffer || len(geom.vertexAttributes) == 1, "Batching requires an interleaved buffer layout") ||
+		!assert.True(geom.primitiveType == gl.POINTS || geom.primitiveType == gl.LINES || geom.primitiveType == gl.TRIANGLES, "Batching does not support primitive type %s", geom.primitiveType) {
+		return
+	}
+
+	if b.vertexCount > 0 && (b.material != material ||
+		b.primitiveType != geom.primitiveType ||
+		!equalStringSlice(b.vertexAttributes, geom.vertexAttributes) ||
+		b.vertexCount+geom.vertexCount > b.MaxVertices) {
+		b.Flush(renderState)
+	}
+	if b.vertexCount == 0 && !b.begin(material, geom) {
+		return
+	}
+	if renderState.batch != b {
+		renderState.flushBatch() // another batch is pending
+		renderState.batch = b
+	}
+
+	// indices
+	firstVtx := uint32(b.vertexCount)
+	for i, count := 0, geom.IndexCount(); i < count; i++ {
+		b.indices = append(b.indices, firstVtx+uint32(geom.Index(i)))
+	}
+
+	// vertices
+	first := len(b.vertices)
+	b.vertices = append(b.vertices, geom.vertices...)
+	if b.posOffset >= 0 {
+		trans := renderState.TransformStack.Top()
+		for v := first + b.posOffset; v < len(b.vertices); v += b.vertexSize {
+			b.transformPosition(trans, b.vertices[v:v+b.posComponents])
+		}
+	}
+	b.vertexCount += geom.vertexCount
+}
+
+// begin prepares the empty batch for geometry with the given properties.
+func (b *Batch) begin(material *Material, geom *Geometry) bool {
+	sProg, _ := resolveShader(material.sProgKey)
+	if !assert.True(sProg != nil, "Shader %q not loaded", material.sProgKey) {
+		return false
+	}
+
+	b.material = material
+	b.primitiveType = geom.primitiveType
+	b.vertexAttributes = geom.vertexAttributes
+	b.vertexSize = len(geom.vertices) / geom.vertexCount
+	b.posOffset = -1
+
+	offset := 0
+	for _, attr := range geom.vertexAttributes {
+		components := int(vaTypePropertyMapping[sProg.attributeTypes[attr]].components)
+		if attr == b.PositionAttribute {
+			b.posOffset, b.posComponents = offset, components
+		}
+		offset += components
+	}
+	return assert.True(b.posOffset < 0 || b.posComponents <= 4, "Position attribute %q can't be transformed", b.PositionAttribute)
+}
+
+// transformPosition applies the transformation to a position with up to 4 components.
+func (b *Batch) transformPosition(trans vmath.Mat4f, pos []float32) {
+	vec := vmath.Vec4f{0, 0, 0, 1}
+	copy(vec[:], pos)
+	vec = trans.MulVec(vec)
+	copy(pos, vec[:])
+}
+
+// Flush draws all accumulated geometry and empties the batch.
+func (b *Batch) Flush(renderState *RenderState) {
+	if renderState.batch == b {
+		renderState.batch = nil
+	}
+	if b.vertexCount == 0 {
+		return
+	}
+
+	b.mesh.SetMaterial(b.material)
+	// batches are never culled, so there's no need to calculate bounds
+	b.mesh.setVertexData32(b.vertexCount, b.vertices, b.indices, b.primitiveType, b.vertexAttributes, InterleavedBuffer)
+
+	// positions are already transformed
+	renderState.TransformStack.Push()
+	renderState.TransformStack.Set(vmath.Ident4f())
+	b.mesh.draw(renderState, b.material) // batches are never queued
+	renderState.TransformStack.Pop()
+
+	b.vertexCount = 0
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+}
+
+// flushBatch draws the pending batch, if there is any.
+// Needs to be called before anything else is drawn.
+func (r *RenderState) flushBatch() {
+	if r.batch != nil {
+		r.batch.Flush(r)
+	}
+}
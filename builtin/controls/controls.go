@@ -0,0 +1,71 @@
+// Package controls provides reusable camera controllers that react to user input.
+//
+// Controllers register callbacks on the engine's InteractionSystem when they are created.
+// Their Update() method needs to be called once per frame (within the frame function) to apply the input
+// to the camera, which allows smoothing and inertia. Call Detach() to remove all callbacks again.
+package controls
+
+import (
+	"time"
+
+	"github.com/maja42/nora"
+	"github.com/maja42/vmath/math32"
+)
+
+// callbacks stores the IDs of all callbacks a controller registered.
+type callbacks struct {
+	interaction *nora.InteractionSystem
+
+	mouseMove   nora.CallbackID
+	mouseButton nora.CallbackID
+	scroll      nora.CallbackID
+}
+
+// detach removes all registered callbacks.
+func (c *callbacks) detach() {
+	if c.interaction == nil {
+		return
+	}
+	c.interaction.RemoveMouseMoveEventFunc(c.mouseMove)
+	c.interaction.RemoveMouseButtonEventFunc(c.mouseButton)
+	c.interaction.RemoveScrollEventFunc(c.scroll)
+	c.interaction = nil
+}
+
+// approach returns the interpolation factor [0, 1] for moving a value towards its target.
+// The time constant (in seconds) is the time needed to cover ~63% of the distance. Zero disables smoothing.
+func approach(timeConstant float32, elapsed time.Duration) float32 {
+	if timeConstant <= 0 {
+		return 1
+	}
+	return 1 - math32.Exp(-float32(elapsed.Seconds())/timeConstant)
+}
+
+// decay returns the factor [0, 1] by which a velocity is reduced within the elapsed time.
+// The time constant (in seconds) is the time needed to lose ~63% of the velocity. Zero stops immediately.
+func decay(timeConstant float32, elapsed time.Duration) float32 {
+	if timeConstant <= 0 {
+		return 0
+	}
+	return math32.Exp(-float32(elapsed.Seconds()) / timeConstant)
+}
+
+// smooth interpolates between two values. Snaps to the target value if it is close enough.
+func smooth(from, to, t float32) float32 {
+	v := from + (to-from)*t
+	if math32.Abs(to-v) <= 1e-5*math32.Max(1, math32.Abs(to)) {
+		return to
+	}
+	return v
+}
+
+// clampOptional limits the value to [min, max]. A zero limit is ignored.
+func clampOptional(v, min, max float32) float32 {
+	if min != 0 && v < min {
+		v = min
+	}
+	if max != 0 && v > max {
+		v = max
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package controls
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/maja42/glfw"
+	"github.com/maja42/nora"
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+// direction returns the normalized vector for the given yaw and pitch angles.
+// A yaw and pitch of zero point along the negative z-axis.
+func direction(yaw, pitch float32) vmath.Vec3f {
+	cosPitch := math32.Cos(pitch)
+	return vmath.Vec3f{-math32.Sin(yaw) * cosPitch, math32.Sin(pitch), -math32.Cos(yaw) * cosPitch}
+}
+
+func TestFlyLook(t *testing.T) {
+	tests := []struct {
+		name     string
+		movement vmath.Vec2i // mouse movement in pixels
+	}{
+		{"yaw", vmath.Vec2i{-200, 0}},
+		{"pitch", vmath.Vec2i{0, -150}},
+		{"yaw and pitch", vmath.Vec2i{300, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cam := nora.NewPerspectiveCamera(1, 1, 0.1, 100)
+			c := newFly(cam)
+			c.cb.interaction = &nora.InteractionSystem{}
+
+			c.onMouseButton(c.LookButton, glfw.Press, 0)
+			c.onMouseMove(vmath.Vec2i{}, tt.movement)
+			c.Update(time.Second / 60)
+
+			yaw := -float32(tt.movement[0]) * c.LookSpeed
+			pitch := -float32(tt.movement[1]) * c.LookSpeed
+			want := direction(yaw, pitch)
+			if fwd := cam.Forward(); fwd.Sub(want).Length() > 1e-5 {
+				t.Errorf("forward is %v, want %v", fwd, want)
+			}
+			if cam.Position() != (vmath.Vec3f{}) {
+				t.Errorf("camera moved to %v without pressed keys", cam.Position())
+			}
+		})
+	}
+}
+
+func TestOrbitOrientation(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaw, pitch float32
+	}{
+		{"front", 0, 0},
+		{"side", math.Pi / 2, 0},
+		{"above", 0.5, 0.8},
+		{"below", -2, -0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := vmath.Vec3f{1, 2, 3}
+			cam := nora.NewPerspectiveCamera(1, 1, 0.1, 100)
+			c := newOrbit(cam, target)
+			c.SetOrientation(tt.yaw, tt.pitch, 5)
+			c.Update(time.Second / 60)
+
+			// positive pitch places the camera above the target, looking downwards
+			want := direction(tt.yaw, -tt.pitch)
+			if pos, wantPos := cam.Position(), target.Sub(want.MulScalar(5)); pos.Sub(wantPos).Length() > 1e-4 {
+				t.Errorf("camera is at %v, want %v", pos, wantPos)
+			}
+			if fwd := cam.Forward(); fwd.Sub(want).Length() > 1e-5 {
+				t.Errorf("forward is %v, want %v", fwd, want)
+			}
+		})
+	}
+}
+
+func TestOrbitDrag(t *testing.T) {
+	target := vmath.Vec3f{0, 0, 0}
+	cam := nora.NewPerspectiveCamera(1, 1, 0.1, 100)
+	cam.SetPosition(vmath.Vec3f{0, 0, 10})
+	c := newOrbit(cam, target)
+	before := cam.Forward()
+
+	c.onMouseButton(c.RotateButton, glfw.Press, 0)
+	c.onMouseMove(vmath.Vec2i{}, vmath.Vec2i{100, 50})
+	c.Update(time.Second / 60)
+
+	fwd := cam.Forward()
+	if fwd.Sub(before).Length() < 0.1 {
+		t.Errorf("dragging did not rotate the camera (forward is %v)", fwd)
+	}
+	if want := target.Sub(cam.Position()).Normalize(); fwd.Sub(want).Length() > 1e-5 {
+		t.Errorf("forward is %v, want %v (towards the target)", fwd, want)
+	}
+}
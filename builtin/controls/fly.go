@@ -0,0 +1,202 @@
+package controls
+
+import (
+	"math"
+	"time"
+
+	"github.com/maja42/glfw"
+	"github.com/maja42/nora"
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+// FlyKeys define the keyboard layout of the fly controller.
+type FlyKeys struct {
+	Forward, Backward glfw.Key
+	Left, Right       glfw.Key
+	Up, Down          glfw.Key
+	Boost             glfw.Key // speed multiplier while pressed
+}
+
+// DefaultFlyKeys uses WASD for moving, E/Q for moving up/down and shift for boosting.
+var DefaultFlyKeys = FlyKeys{
+	Forward:  glfw.KeyW,
+	Backward: glfw.KeyS,
+	Left:     glfw.KeyA,
+	Right:    glfw.KeyD,
+	Up:       glfw.KeyE,
+	Down:     glfw.KeyQ,
+	Boost:    glfw.KeyLeftShift,
+}
+
+// Fly controls a perspective camera that moves freely through the scene.
+// The keyboard moves the camera, dragging with the look button rotates it and scrolling changes the speed.
+type Fly struct {
+	camera *nora.PerspectiveCamera
+	cb     callbacks
+
+	// Keys defines the keyboard layout.
+	Keys FlyKeys
+	// LookButton is the mouse button that needs to be pressed for looking around.
+	LookButton glfw.MouseButton
+	// LookSpeed is the rotation in radians per moved pixel.
+	LookSpeed float32
+	// Speed is the movement speed in world units per second.
+	Speed float32
+	// BoostFactor is the speed multiplier while the boost key is pressed.
+	BoostFactor float32
+	// SpeedFactor is the speed scaling applied per scroll step. Must be >1.
+	SpeedFactor float32
+	// MinSpeed and MaxSpeed limit the movement speed. Zero means unlimited.
+	MinSpeed float32
+	MaxSpeed float32
+	// BoundsMin and BoundsMax limit the camera position. If both are zero, the position is unlimited.
+	BoundsMin vmath.Vec3f
+	BoundsMax vmath.Vec3f
+	// Smoothing is the time constant (in seconds) for approaching the desired orientation. Zero disables smoothing.
+	Smoothing float32
+	// Inertia is the time constant (in seconds) for accelerating and slowing down. Zero disables inertia.
+	Inertia float32
+
+	looking  bool
+	velocity vmath.Vec3f // camera space, world units per second
+
+	// desired orientation
+	yaw   float32
+	pitch float32
+	// current orientation
+	curYaw   float32
+	curPitch float32
+}
+
+// NewFly creates a new controller and registers it on the interaction system.
+// The camera's roll is removed.
+func NewFly(interaction *nora.InteractionSystem, camera *nora.PerspectiveCamera) *Fly {
+	c := newFly(camera)
+	c.cb = callbacks{
+		interaction: interaction,
+		mouseButton: interaction.OnMouseButtonEvent(c.onMouseButton),
+		mouseMove:   interaction.OnMouseMoveEvent(c.onMouseMove),
+		scroll:      interaction.OnScroll(c.onScroll),
+	}
+	return c
+}
+
+// newFly creates a controller without registering any callbacks.
+func newFly(camera *nora.PerspectiveCamera) *Fly {
+	fwd := camera.Forward()
+	c := &Fly{
+		camera:      camera,
+		Keys:        DefaultFlyKeys,
+		LookButton:  glfw.MouseButtonRight,
+		LookSpeed:   0.003,
+		Speed:       5,
+		BoostFactor: 4,
+		SpeedFactor: 1.2,
+
+		yaw:   math32.Atan2(-fwd[0], -fwd[2]),
+		pitch: math32.Asin(vmath.Clampf(fwd[1], -1, 1)),
+	}
+	c.curYaw, c.curPitch = c.yaw, c.pitch
+	c.applyOrientation()
+	return c
+}
+
+// Detach removes all callbacks from the interaction system.
+// The controller must not be used afterwards.
+func (c *Fly) Detach() {
+	c.cb.detach()
+}
+
+// Stop cancels all ongoing movements and skips smoothing.
+func (c *Fly) Stop() {
+	c.velocity = vmath.Vec3f{}
+	c.curYaw, c.curPitch = c.yaw, c.pitch
+	c.applyOrientation()
+}
+
+func (c *Fly) onMouseButton(button glfw.MouseButton, action glfw.Action, _ glfw.ModifierKey) {
+	if button == c.LookButton {
+		c.looking = action != glfw.Release
+	}
+}
+
+func (c *Fly) onMouseMove(_, movement vmath.Vec2i) {
+	if !c.looking {
+		return
+	}
+	const maxPitch = math.Pi/2 - 0.01
+	c.yaw -= float32(movement[0]) * c.LookSpeed
+	c.pitch = vmath.Clampf(c.pitch-float32(movement[1])*c.LookSpeed, -maxPitch, maxPitch)
+}
+
+func (c *Fly) onScroll(offset vmath.Vec2i) {
+	if offset[1] == 0 {
+		return
+	}
+	speed := c.Speed * math32.Pow(c.SpeedFactor, float32(offset[1]))
+	c.Speed = clampOptional(speed, c.MinSpeed, c.MaxSpeed)
+}
+
+// axis returns -1, 0 or +1, depending on which of the two keys is pressed.
+func (c *Fly) axis(negative, positive glfw.Key) float32 {
+	var v float32
+	if c.cb.interaction.IsKeyPressed(negative) {
+		v--
+	}
+	if c.cb.interaction.IsKeyPressed(positive) {
+		v++
+	}
+	return v
+}
+
+// Update applies the user input to the camera.
+// Needs to be called once per frame.
+func (c *Fly) Update(elapsed time.Duration) {
+	if c.curYaw != c.yaw || c.curPitch != c.pitch {
+		t := approach(c.Smoothing, elapsed)
+		c.curYaw = smooth(c.curYaw, c.yaw, t)
+		c.curPitch = smooth(c.curPitch, c.pitch, t)
+		c.applyOrientation()
+	}
+
+	// desired velocity in camera space (right, up, forward)
+	desired := vmath.Vec3f{
+		c.axis(c.Keys.Left, c.Keys.Right),
+		c.axis(c.Keys.Down, c.Keys.Up),
+		c.axis(c.Keys.Backward, c.Keys.Forward),
+	}
+	if !desired.IsZero() {
+		speed := c.Speed
+		if c.cb.interaction.IsKeyPressed(c.Keys.Boost) {
+			speed *= c.BoostFactor
+		}
+		desired = desired.Normalize().MulScalar(speed)
+	}
+
+	t := 1 - decay(c.Inertia, elapsed)
+	for i := range c.velocity {
+		c.velocity[i] = smooth(c.velocity[i], desired[i], t)
+	}
+	if c.velocity.IsZero() {
+		return
+	}
+
+	dt := float32(elapsed.Seconds())
+	c.camera.MoveRelative(c.velocity[0]*dt, c.velocity[1]*dt, c.velocity[2]*dt)
+	if c.BoundsMin != (vmath.Vec3f{}) || c.BoundsMax != (vmath.Vec3f{}) {
+		pos := c.camera.Position()
+		for i := range pos {
+			pos[i] = vmath.Clampf(pos[i], c.BoundsMin[i], c.BoundsMax[i])
+		}
+		if pos != c.camera.Position() {
+			c.camera.SetPosition(pos)
+		}
+	}
+}
+
+func (c *Fly) applyOrientation() {
+	yawRot := vmath.QuatFromAxisAngle(vmath.Vec3f{0, 1, 0}, c.curYaw)
+	pitchRot := vmath.QuatFromAxisAngle(vmath.Vec3f{1, 0, 0}, c.curPitch)
+	c.camera.SetOrientation(pitchRot.Rotate(yawRot)) // Rotate() multiplies in reverse order (yaw * pitch)
+}
@@ -0,0 +1,215 @@
+package controls
+
+import (
+	"math"
+	"time"
+
+	"github.com/maja42/glfw"
+	"github.com/maja42/nora"
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+// Orbit controls a perspective camera that circles around a target position.
+// Dragging with the rotate button orbits around the target, dragging with the pan button moves the target,
+// and scrolling changes the distance.
+type Orbit struct {
+	camera *nora.PerspectiveCamera
+	cb     callbacks
+
+	// RotateButton is the mouse button that needs to be pressed for orbiting.
+	RotateButton glfw.MouseButton
+	// PanButton is the mouse button that needs to be pressed for moving the target.
+	PanButton glfw.MouseButton
+	// RotateSpeed is the rotation in radians per moved pixel.
+	RotateSpeed float32
+	// ZoomFactor is the distance scaling applied per scroll step. Must be >1.
+	ZoomFactor float32
+	// MinDistance and MaxDistance limit the distance to the target. Zero means unlimited.
+	MinDistance float32
+	MaxDistance float32
+	// MinPitch and MaxPitch limit the vertical angle in radians. Must be within ]-pi/2, +pi/2[.
+	MinPitch float32
+	MaxPitch float32
+	// Smoothing is the time constant (in seconds) for approaching the desired orientation and distance. Zero disables smoothing.
+	Smoothing float32
+	// Inertia is the time constant (in seconds) for slowing down after rotating. Zero disables inertia.
+	Inertia float32
+
+	rotating  bool
+	panning   bool
+	dragDelta vmath.Vec2f // yaw/pitch change since the last update
+	velocity  vmath.Vec2f // yaw/pitch change per second
+
+	// desired state
+	target   vmath.Vec3f
+	yaw      float32
+	pitch    float32
+	distance float32
+
+	// current state
+	curTarget   vmath.Vec3f
+	curYaw      float32
+	curPitch    float32
+	curDistance float32
+}
+
+// NewOrbit creates a new controller and registers it on the interaction system.
+// The camera is moved to look at the target from its current direction.
+func NewOrbit(interaction *nora.InteractionSystem, camera *nora.PerspectiveCamera, target vmath.Vec3f) *Orbit {
+	c := newOrbit(camera, target)
+	c.cb = callbacks{
+		interaction: interaction,
+		mouseButton: interaction.OnMouseButtonEvent(c.onMouseButton),
+		mouseMove:   interaction.OnMouseMoveEvent(c.onMouseMove),
+		scroll:      interaction.OnScroll(c.onScroll),
+	}
+	return c
+}
+
+// newOrbit creates a controller without registering any callbacks.
+func newOrbit(camera *nora.PerspectiveCamera, target vmath.Vec3f) *Orbit {
+	c := &Orbit{
+		camera:       camera,
+		RotateButton: glfw.MouseButtonLeft,
+		PanButton:    glfw.MouseButtonRight,
+		RotateSpeed:  0.005,
+		ZoomFactor:   1.1,
+		MinPitch:     -math.Pi/2 + 0.01,
+		MaxPitch:     math.Pi/2 - 0.01,
+	}
+	c.SetTarget(target)
+	offset := camera.Position().Sub(target)
+	distance := offset.Length()
+	if distance == 0 {
+		offset, distance = vmath.Vec3f{0, 0, 1}, 1
+	}
+	c.SetOrientation(math32.Atan2(offset[0], offset[2]), math32.Asin(offset[1]/distance), distance)
+	c.snap()
+	c.apply()
+	return c
+}
+
+// Detach removes all callbacks from the interaction system.
+// The controller must not be used afterwards.
+func (c *Orbit) Detach() {
+	c.cb.detach()
+}
+
+// Target returns the position the camera circles around.
+func (c *Orbit) Target() vmath.Vec3f {
+	return c.target
+}
+
+// SetTarget changes the position the camera circles around.
+func (c *Orbit) SetTarget(target vmath.Vec3f) {
+	c.target = target
+}
+
+// SetOrientation changes the camera's position relative to the target.
+//	- yaw 		Horizontal angle in radians; 0 = camera is located in positive z-direction of the target
+//	- pitch 	Vertical angle in radians; positive values = camera is located above the target
+//	- distance 	Distance between camera and target
+func (c *Orbit) SetOrientation(yaw, pitch, distance float32) {
+	c.yaw = yaw
+	c.pitch = vmath.Clampf(pitch, c.MinPitch, c.MaxPitch)
+	c.distance = clampOptional(distance, c.MinDistance, c.MaxDistance)
+}
+
+// Stop cancels all ongoing movements and skips smoothing.
+func (c *Orbit) Stop() {
+	c.velocity = vmath.Vec2f{}
+	c.snap()
+	c.apply()
+}
+
+// snap sets the current state to the desired state.
+func (c *Orbit) snap() {
+	c.curTarget, c.curYaw, c.curPitch, c.curDistance = c.target, c.yaw, c.pitch, c.distance
+}
+
+func (c *Orbit) onMouseButton(button glfw.MouseButton, action glfw.Action, _ glfw.ModifierKey) {
+	pressed := action != glfw.Release
+	switch button {
+	case c.RotateButton:
+		c.rotating = pressed
+		if pressed {
+			c.velocity = vmath.Vec2f{}
+		}
+	case c.PanButton:
+		c.panning = pressed
+	}
+}
+
+func (c *Orbit) onMouseMove(_, movement vmath.Vec2i) {
+	if c.rotating {
+		delta := vmath.Vec2f{-float32(movement[0]), float32(movement[1])}.MulScalar(c.RotateSpeed)
+		c.rotate(delta)
+		c.dragDelta = c.dragDelta.Add(delta)
+	}
+	if c.panning {
+		// move the target with the cursor, based on the visible area at the target's distance
+		content := c.cb.interaction.ContentArea()
+		contentHeight := float32(content.Max[1] - content.Min[1])
+		worldPerPixel := 2 * c.distance * math32.Tan(c.camera.FieldOfView()/2) / contentHeight
+		right := c.camera.Right().MulScalar(-float32(movement[0]) * worldPerPixel)
+		up := c.camera.Up().MulScalar(float32(movement[1]) * worldPerPixel)
+		c.target = c.target.Add(right).Add(up)
+	}
+}
+
+func (c *Orbit) onScroll(offset vmath.Vec2i) {
+	if offset[1] == 0 {
+		return
+	}
+	distance := c.distance * math32.Pow(c.ZoomFactor, float32(-offset[1]))
+	c.distance = clampOptional(distance, c.MinDistance, c.MaxDistance)
+}
+
+func (c *Orbit) rotate(delta vmath.Vec2f) {
+	c.yaw += delta[0]
+	c.pitch = vmath.Clampf(c.pitch+delta[1], c.MinPitch, c.MaxPitch)
+}
+
+// Update applies the user input to the camera.
+// Needs to be called once per frame.
+func (c *Orbit) Update(elapsed time.Duration) {
+	dt := float32(elapsed.Seconds())
+	if c.rotating {
+		if dt > 0 {
+			c.velocity = c.dragDelta.DivScalar(dt)
+		}
+	} else if !c.velocity.IsZero() {
+		c.velocity = c.velocity.MulScalar(decay(c.Inertia, elapsed))
+		if c.velocity.SquareLength() < 1e-12 {
+			c.velocity = vmath.Vec2f{}
+		}
+		c.rotate(c.velocity.MulScalar(dt))
+	}
+	c.dragDelta = vmath.Vec2f{}
+
+	if c.curTarget == c.target && c.curYaw == c.yaw && c.curPitch == c.pitch && c.curDistance == c.distance {
+		return // nothing changed; keep the camera's change-counter
+	}
+
+	t := approach(c.Smoothing, elapsed)
+	for i := range c.curTarget {
+		c.curTarget[i] = smooth(c.curTarget[i], c.target[i], t)
+	}
+	c.curYaw = smooth(c.curYaw, c.yaw, t)
+	c.curPitch = smooth(c.curPitch, c.pitch, t)
+	c.curDistance = smooth(c.curDistance, c.distance, t)
+	c.apply()
+}
+
+// apply moves the camera according to the current state.
+func (c *Orbit) apply() {
+	cosPitch := math32.Cos(c.curPitch)
+	offset := vmath.Vec3f{
+		math32.Sin(c.curYaw) * cosPitch,
+		math32.Sin(c.curPitch),
+		math32.Cos(c.curYaw) * cosPitch,
+	}
+	c.camera.SetPosition(c.curTarget.Add(offset.MulScalar(c.curDistance)))
+	c.camera.LookAt(c.curTarget)
+}
@@ -0,0 +1,167 @@
+package controls
+
+import (
+	"time"
+
+	"github.com/maja42/glfw"
+	"github.com/maja42/nora"
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+// PanZoom2D controls an orthographic camera for 2D scenes.
+// Dragging with the mouse moves the camera, scrolling zooms towards the cursor.
+type PanZoom2D struct {
+	camera *nora.OrthoCamera
+	cb     callbacks
+
+	// PanButton is the mouse button that needs to be pressed for panning.
+	PanButton glfw.MouseButton
+	// ZoomFactor is the scaling applied per scroll step. Must be >1.
+	ZoomFactor float32
+	// ZoomToCursor keeps the world position below the cursor fixed while zooming.
+	// Otherwise, the camera zooms towards the screen center.
+	ZoomToCursor bool
+	// MinOrthoWidth and MaxOrthoWidth limit zooming. Zero means unlimited.
+	MinOrthoWidth float32
+	MaxOrthoWidth float32
+	// Bounds limit the camera center. An empty rectangle means unlimited.
+	Bounds vmath.Rectf
+	// Smoothing is the time constant (in seconds) for approaching the desired camera position and zoom. Zero disables smoothing.
+	Smoothing float32
+	// Inertia is the time constant (in seconds) for slowing down after panning. Zero disables inertia.
+	Inertia float32
+
+	dragging  bool
+	dragDelta vmath.Vec2f // target position change since the last update
+	velocity  vmath.Vec2f // world units per second
+
+	pos        vmath.Vec2f // target camera position
+	width      float32     // target ortho width
+	dirtyCount int         // camera modifications that are already known
+}
+
+// NewPanZoom2D creates a new controller and registers it on the interaction system.
+func NewPanZoom2D(interaction *nora.InteractionSystem, camera *nora.OrthoCamera) *PanZoom2D {
+	c := &PanZoom2D{
+		camera:       camera,
+		PanButton:    glfw.MouseButtonLeft,
+		ZoomFactor:   1.1,
+		ZoomToCursor: true,
+	}
+	c.syncWithCamera()
+
+	c.cb = callbacks{
+		interaction: interaction,
+		mouseButton: interaction.OnMouseButtonEvent(c.onMouseButton),
+		mouseMove:   interaction.OnMouseMoveEvent(c.onMouseMove),
+		scroll:      interaction.OnScroll(c.onScroll),
+	}
+	return c
+}
+
+// Detach removes all callbacks from the interaction system.
+// The controller must not be used afterwards.
+func (c *PanZoom2D) Detach() {
+	c.cb.detach()
+}
+
+// Stop cancels all ongoing movements.
+func (c *PanZoom2D) Stop() {
+	c.velocity = vmath.Vec2f{}
+	c.syncWithCamera()
+}
+
+// syncWithCamera applies the camera's current state as target state.
+// Called if the camera was modified by someone else.
+func (c *PanZoom2D) syncWithCamera() {
+	c.pos = c.camera.Position()
+	c.width = c.camera.OrthoWidth()
+	c.dirtyCount = c.camera.DirtyCount()
+}
+
+func (c *PanZoom2D) onMouseButton(button glfw.MouseButton, action glfw.Action, _ glfw.ModifierKey) {
+	if button != c.PanButton {
+		return
+	}
+	switch action {
+	case glfw.Press:
+		c.dragging = true
+		c.velocity = vmath.Vec2f{}
+	case glfw.Release:
+		c.dragging = false
+	}
+}
+
+func (c *PanZoom2D) onMouseMove(_, movement vmath.Vec2i) {
+	if !c.dragging {
+		return
+	}
+	clipDist := c.cb.interaction.WindowSpaceDistToClipSpaceDist(movement.Vec2f())
+	worldDist := c.camera.ClipSpaceDistToWorldSpaceDist(clipDist)
+	// the world moves with the cursor; the camera moves into the opposite direction
+	c.pos = c.pos.Sub(worldDist)
+	c.dragDelta = c.dragDelta.Sub(worldDist)
+}
+
+func (c *PanZoom2D) onScroll(offset vmath.Vec2i) {
+	if offset[1] == 0 {
+		return
+	}
+	width := c.width * math32.Pow(c.ZoomFactor, float32(-offset[1]))
+	width = clampOptional(width, c.MinOrthoWidth, c.MaxOrthoWidth)
+
+	if c.ZoomToCursor {
+		// keep the world position below the cursor fixed
+		cursor := c.cb.interaction.MousePosClipSpace()
+		aspectRatio := c.camera.AspectRatio()
+		oldHalf := vmath.Vec2f{c.width / 2, c.width / 2 / aspectRatio}
+		newHalf := vmath.Vec2f{width / 2, width / 2 / aspectRatio}
+
+		world := c.pos.Add(cursor.Mul(oldHalf))
+		c.pos = world.Sub(cursor.Mul(newHalf))
+	}
+	c.width = width
+}
+
+// Update applies the user input to the camera.
+// Needs to be called once per frame.
+func (c *PanZoom2D) Update(elapsed time.Duration) {
+	if c.camera.DirtyCount() != c.dirtyCount { // modified by someone else
+		c.syncWithCamera()
+	}
+
+	dt := float32(elapsed.Seconds())
+	if c.dragging {
+		if dt > 0 {
+			c.velocity = c.dragDelta.DivScalar(dt)
+		}
+	} else if !c.velocity.IsZero() {
+		c.velocity = c.velocity.MulScalar(decay(c.Inertia, elapsed))
+		if c.velocity.SquareLength() < 1e-12 {
+			c.velocity = vmath.Vec2f{}
+		}
+		c.pos = c.pos.Add(c.velocity.MulScalar(dt))
+	}
+	c.dragDelta = vmath.Vec2f{}
+	c.pos = c.clampPosition(c.pos)
+
+	t := approach(c.Smoothing, elapsed)
+	if width := c.camera.OrthoWidth(); width != c.width {
+		c.camera.SetOrthoWidth(smooth(width, c.width, t))
+	}
+	if pos := c.camera.Position(); pos != c.pos {
+		c.camera.SetPositionXY(smooth(pos[0], c.pos[0], t), smooth(pos[1], c.pos[1], t))
+	}
+	c.dirtyCount = c.camera.DirtyCount()
+}
+
+func (c *PanZoom2D) clampPosition(pos vmath.Vec2f) vmath.Vec2f {
+	if c.Bounds == (vmath.Rectf{}) {
+		return pos
+	}
+	bounds := c.Bounds.Normalize()
+	pos[0] = vmath.Clampf(pos[0], bounds.Min[0], bounds.Max[0])
+	pos[1] = vmath.Clampf(pos[1], bounds.Min[1], bounds.Max[1])
+	return pos
+}
@@ -49,5 +49,7 @@ func Border(r vmath.Rectf, borderWidth float32, color color.Color) *nora.Geometr
 		2, 3, 6, 3, 7, 6, // top
 		3, 0, 7, 0, 4, 7, // left
 	}
-	return nora.NewGeometry(8, vertices, indices, gl.TRIANGLES, []string{"position", "color"}, nora.InterleavedBuffer)
+	geom := nora.NewGeometry(8, vertices, indices, gl.TRIANGLES, []string{"position", "color"}, nora.InterleavedBuffer)
+	geom.SetAttributeComponents(2, 3)
+	return geom
 }
@@ -0,0 +1,761 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora"
+	"github.com/maja42/nora/builtin/shader"
+	"github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+	"github.com/sirupsen/logrus"
+)
+
+// gltfDocument contains the supported subset of a glTF 2.0 file.
+type gltfDocument struct {
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Name        string    `json:"name"`
+		Children    []int     `json:"children"`
+		Mesh        *int      `json:"mesh"`
+		Matrix      []float32 `json:"matrix"`
+		Translation []float32 `json:"translation"`
+		Rotation    []float32 `json:"rotation"`
+		Scale       []float32 `json:"scale"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       *int           `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		PBR struct {
+			BaseColorFactor  []float32 `json:"baseColorFactor"`
+			BaseColorTexture *struct {
+				Index int `json:"index"`
+			} `json:"baseColorTexture"`
+		} `json:"pbrMetallicRoughness"`
+		AlphaMode string `json:"alphaMode"`
+	} `json:"materials"`
+	Textures []struct {
+		Source  *int `json:"source"`
+		Sampler *int `json:"sampler"`
+	} `json:"textures"`
+	Samplers []struct {
+		MagFilter gl.Enum `json:"magFilter"`
+		MinFilter gl.Enum `json:"minFilter"`
+		WrapS     gl.Enum `json:"wrapS"`
+		WrapT     gl.Enum `json:"wrapT"`
+	} `json:"samplers"`
+	Images []struct {
+		URI        string `json:"uri"`
+		BufferView *int   `json:"bufferView"`
+	} `json:"images"`
+	Accessors []struct {
+		BufferView    *int    `json:"bufferView"`
+		ByteOffset    int     `json:"byteOffset"`
+		ComponentType gl.Enum `json:"componentType"`
+		Normalized    bool    `json:"normalized"`
+		Count         int     `json:"count"`
+		Type          string  `json:"type"`
+		Sparse        *struct {
+			Count   int `json:"count"`
+			Indices struct {
+				BufferView    int     `json:"bufferView"`
+				ByteOffset    int     `json:"byteOffset"`
+				ComponentType gl.Enum `json:"componentType"`
+			} `json:"indices"`
+			Values struct {
+				BufferView int `json:"bufferView"`
+				ByteOffset int `json:"byteOffset"`
+			} `json:"values"`
+		} `json:"sparse"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+}
+
+// gltfLoader converts a glTF document into models.
+type gltfLoader struct {
+	path    string
+	doc     gltfDocument
+	buffers [][]byte
+
+	resources *modelResources
+	meshes    map[int][]*nora.Mesh // glTF mesh index -> meshes of all primitives
+}
+
+var gltfComponents = map[string]int{
+	"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16,
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// LoadGLTF loads the default scene of a glTF 2.0 file (.gltf or .glb).
+// Every glTF node becomes a child model with the node's transformation.
+// Materials use the base color factor and texture, with the builtin COL_TEX_NORM_3D shader, or COL_NORM_3D if they have no texture.
+// Images (external or embedded) are loaded into the given store and unloaded when the model is destroyed.
+func LoadGLTF(path string, textureStore *nora.TextureStore) (*Model, error) {
+	logrus.Infof("Loading glTF model %q...", filepath.Base(path))
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &gltfLoader{
+		path:   path,
+		meshes: make(map[int][]*nora.Mesh),
+	}
+	var bin []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, bin, err = parseGLB(data); err != nil {
+			return nil, fmt.Errorf("parse %q: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+	if err := l.loadBuffers(bin); err != nil {
+		return nil, fmt.Errorf("load %q: %w", path, err)
+	}
+
+	model := newModel(textureStore)
+	model.name = filepath.Base(path)
+	l.resources = model.resources
+	if err := l.loadScene(model); err != nil {
+		model.Destroy()
+		return nil, fmt.Errorf("load %q: %w", path, err)
+	}
+	logrus.Infof("glTF model %q: %d nodes, %d meshes", filepath.Base(path), len(l.doc.Nodes), len(l.resources.meshes))
+	return model, nil
+}
+
+// parseGLB splits a binary glTF file into its JSON and binary chunk.
+func parseGLB(data []byte) ([]byte, []byte, error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glTF version %d", version)
+	}
+	if length := int(binary.LittleEndian.Uint32(data[8:])); length <= len(data) {
+		data = data[:length]
+	}
+
+	var jsonChunk, binChunk []byte
+	for offset := 12; offset+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+length > len(data) {
+			return nil, nil, errors.New("truncated chunk")
+		}
+		chunk := data[offset : offset+length]
+		switch {
+		case chunkType == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case chunkType == glbChunkBIN && binChunk == nil:
+			binChunk = chunk
+		}
+		offset += length
+	}
+	if jsonChunk == nil {
+		return nil, nil, errors.New("missing JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+func (l *gltfLoader) loadBuffers(bin []byte) error {
+	l.buffers = make([][]byte, len(l.doc.Buffers))
+	for i, buf := range l.doc.Buffers {
+		if buf.URI == "" { // GLB-stored buffer
+			if i != 0 || bin == nil {
+				return fmt.Errorf("buffer %d: missing uri", i)
+			}
+			l.buffers[i] = bin
+		} else {
+			data, err := l.readURI(buf.URI)
+			if err != nil {
+				return fmt.Errorf("buffer %d: %w", i, err)
+			}
+			l.buffers[i] = data
+		}
+		if len(l.buffers[i]) < buf.ByteLength {
+			return fmt.Errorf("buffer %d: expected %d bytes, got %d", i, buf.ByteLength, len(l.buffers[i]))
+		}
+	}
+	return nil
+}
+
+// readURI returns the content of a data URI or a file relative to the glTF file.
+func (l *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		sep := strings.Index(uri, ";base64,")
+		if sep < 0 {
+			return nil, errors.New("unsupported data uri")
+		}
+		return base64.StdEncoding.DecodeString(uri[sep+len(";base64,"):])
+	}
+	return ioutil.ReadFile(l.filePath(uri))
+}
+
+func (l *gltfLoader) filePath(uri string) string {
+	if path, err := url.PathUnescape(uri); err == nil {
+		uri = path
+	}
+	return filepath.Join(filepath.Dir(l.path), filepath.FromSlash(uri))
+}
+
+func (l *gltfLoader) loadScene(root *Model) error {
+	var nodes []int
+	if len(l.doc.Scenes) > 0 {
+		scene := 0
+		if l.doc.Scene != nil {
+			scene = *l.doc.Scene
+		}
+		if scene < 0 || scene >= len(l.doc.Scenes) {
+			return fmt.Errorf("scene %d does not exist", scene)
+		}
+		nodes = l.doc.Scenes[scene].Nodes
+	} else { // no scenes: use all root nodes
+		isChild := make(map[int]bool)
+		for _, node := range l.doc.Nodes {
+			for _, child := range node.Children {
+				isChild[child] = true
+			}
+		}
+		for n := range l.doc.Nodes {
+			if !isChild[n] {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	visited := make(map[int]bool)
+	for _, n := range nodes {
+		child, err := l.loadNode(n, visited)
+		if err != nil {
+			return err
+		}
+		root.children = append(root.children, child)
+	}
+	return nil
+}
+
+func (l *gltfLoader) loadNode(n int, visited map[int]bool) (*Model, error) {
+	if n < 0 || n >= len(l.doc.Nodes) {
+		return nil, fmt.Errorf("node %d does not exist", n)
+	}
+	if visited[n] {
+		return nil, fmt.Errorf("node %d: cyclic or shared node", n)
+	}
+	visited[n] = true
+	node := l.doc.Nodes[n]
+
+	model := newChildModel(node.Name)
+	if len(node.Matrix) == 16 {
+		var mat vmath.Mat4f
+		copy(mat[:], node.Matrix)
+		trans, rot, scale := decomposeMatrix(mat)
+		model.SetPosition(trans)
+		model.SetRotation(rot)
+		model.SetScale(scale)
+	} else {
+		if len(node.Translation) == 3 {
+			model.SetPosition(vmath.Vec3f{node.Translation[0], node.Translation[1], node.Translation[2]})
+		}
+		if len(node.Rotation) == 4 { // x, y, z, w
+			model.SetRotation(vmath.Quat{W: node.Rotation[3], X: node.Rotation[0], Y: node.Rotation[1], Z: node.Rotation[2]})
+		}
+		if len(node.Scale) == 3 {
+			model.SetScale(vmath.Vec3f{node.Scale[0], node.Scale[1], node.Scale[2]})
+		}
+	}
+
+	if node.Mesh != nil {
+		meshes, err := l.loadMesh(*node.Mesh)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %w", n, err)
+		}
+		model.meshes = meshes
+	}
+	for _, c := range node.Children {
+		child, err := l.loadNode(c, visited)
+		if err != nil {
+			return nil, err
+		}
+		model.children = append(model.children, child)
+	}
+	return model, nil
+}
+
+// decomposeMatrix splits an affine transformation into translation, rotation and scaling.
+func decomposeMatrix(m vmath.Mat4f) (vmath.Vec3f, vmath.Quat, vmath.Vec3f) {
+	trans := vmath.Vec3f{m[12], m[13], m[14]}
+	x, y, z := vmath.Vec3f{m[0], m[1], m[2]}, vmath.Vec3f{m[4], m[5], m[6]}, vmath.Vec3f{m[8], m[9], m[10]}
+	scale := vmath.Vec3f{x.Length(), y.Length(), z.Length()}
+	if x.Cross(y).Dot(z) < 0 {
+		scale[0] = -scale[0] // mirrored
+	}
+	for i := range scale {
+		if scale[i] == 0 {
+			return trans, vmath.IdentQuat(), scale
+		}
+	}
+
+	// rotation matrix (row, column)
+	r := func(row, col int) float32 { return m[col*4+row] / scale[col] }
+	var q vmath.Quat
+	if trace := r(0, 0) + r(1, 1) + r(2, 2); trace > 0 {
+		s := float32(math.Sqrt(float64(trace+1))) * 2
+		q = vmath.Quat{W: 0.25 * s, X: (r(2, 1) - r(1, 2)) / s, Y: (r(0, 2) - r(2, 0)) / s, Z: (r(1, 0) - r(0, 1)) / s}
+	} else if r(0, 0) > r(1, 1) && r(0, 0) > r(2, 2) {
+		s := float32(math.Sqrt(float64(1+r(0, 0)-r(1, 1)-r(2, 2)))) * 2
+		q = vmath.Quat{W: (r(2, 1) - r(1, 2)) / s, X: 0.25 * s, Y: (r(0, 1) + r(1, 0)) / s, Z: (r(0, 2) + r(2, 0)) / s}
+	} else if r(1, 1) > r(2, 2) {
+		s := float32(math.Sqrt(float64(1+r(1, 1)-r(0, 0)-r(2, 2)))) * 2
+		q = vmath.Quat{W: (r(0, 2) - r(2, 0)) / s, X: (r(0, 1) + r(1, 0)) / s, Y: 0.25 * s, Z: (r(1, 2) + r(2, 1)) / s}
+	} else {
+		s := float32(math.Sqrt(float64(1+r(2, 2)-r(0, 0)-r(1, 1)))) * 2
+		q = vmath.Quat{W: (r(1, 0) - r(0, 1)) / s, X: (r(0, 2) + r(2, 0)) / s, Y: (r(1, 2) + r(2, 1)) / s, Z: 0.25 * s}
+	}
+	return trans, q.Normalize(), scale
+}
+
+// loadMesh creates one nora mesh per primitive. Meshes referenced by multiple nodes are shared.
+func (l *gltfLoader) loadMesh(idx int) ([]*nora.Mesh, error) {
+	if meshes, ok := l.meshes[idx]; ok {
+		return meshes, nil
+	}
+	if idx < 0 || idx >= len(l.doc.Meshes) {
+		return nil, fmt.Errorf("mesh %d does not exist", idx)
+	}
+
+	var meshes []*nora.Mesh
+	for p, prim := range l.doc.Meshes[idx].Primitives {
+		geom, err := l.loadPrimitive(prim.Attributes, prim.Indices, prim.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d, primitive %d: %w", idx, p, err)
+		}
+		texCoords := len(geom.VertexAttributes()) == 3
+		mat, textured, err := l.loadMaterial(prim.Material, texCoords)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d, primitive %d: %w", idx, p, err)
+		}
+		if texCoords && !textured {
+			geom = removeGeometryTexCoords(geom)
+		}
+		meshes = append(meshes, l.resources.newMesh(geom, mat))
+	}
+	l.meshes[idx] = meshes
+	return meshes, nil
+}
+
+func (l *gltfLoader) loadPrimitive(attributes map[string]int, indicesAccessor, mode *int) (*nora.Geometry, error) {
+	posAccessor, ok := attributes["POSITION"]
+	if !ok {
+		return nil, errors.New("missing POSITION attribute")
+	}
+	positions, err := l.readFloats(posAccessor, 3)
+	if err != nil {
+		return nil, fmt.Errorf("POSITION: %w", err)
+	}
+	vertexCount := len(positions) / 3
+
+	var indices []uint32
+	if indicesAccessor != nil {
+		if indices, err = l.readIndices(*indicesAccessor, vertexCount); err != nil {
+			return nil, fmt.Errorf("indices: %w", err)
+		}
+	}
+	primitiveType := nora.PrimitiveType(gl.TRIANGLES)
+	if mode != nil { // glTF modes are equal to OpenGL primitive types
+		primitiveType = nora.PrimitiveType(*mode)
+	}
+
+	var normals []float32
+	if accessor, ok := attributes["NORMAL"]; ok {
+		if normals, err = l.readFloats(accessor, 3); err != nil {
+			return nil, fmt.Errorf("NORMAL: %w", err)
+		}
+	} else {
+		normals = vertexNormals(positions, indices, primitiveType)
+	}
+	var texCoords []float32
+	if accessor, ok := attributes["TEXCOORD_0"]; ok {
+		if texCoords, err = l.readFloats(accessor, 2); err != nil {
+			return nil, fmt.Errorf("TEXCOORD_0: %w", err)
+		}
+	}
+	if len(normals) != len(positions) || (texCoords != nil && len(texCoords)/2 != vertexCount) {
+		return nil, errors.New("vertex attributes have different lengths")
+	}
+
+	stride := 6
+	attribs := []string{"position", "normal"}
+	if texCoords != nil {
+		stride = 8
+		attribs = append(attribs, "texCoord")
+	}
+	vertices := make([]float32, 0, vertexCount*stride)
+	for v := 0; v < vertexCount; v++ {
+		vertices = append(vertices, positions[v*3:v*3+3]...)
+		vertices = append(vertices, normals[v*3:v*3+3]...)
+		if texCoords != nil {
+			// glTF textures have their origin in the top-left corner, like go images.
+			// The builtin shaders flip the t-coordinate (by sampling -t), which is reverted here.
+			// Using 1-t instead would only work for repeating textures.
+			vertices = append(vertices, texCoords[v*2], -texCoords[v*2+1])
+		}
+	}
+	geom := nora.NewGeometry32(vertexCount, vertices, indices, primitiveType, attribs, nora.InterleavedBuffer)
+	if texCoords != nil {
+		geom.SetAttributeComponents(3, 3, 2)
+	} else {
+		geom.SetAttributeComponents(3, 3)
+	}
+	return geom, nil
+}
+
+// accessorData returns the accessor's data, starting at the first element, and the stride between elements.
+// Sparse accessors are resolved into a tightly packed copy.
+func (l *gltfLoader) accessorData(idx int) (data []byte, stride int, err error) {
+	if idx < 0 || idx >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d does not exist", idx)
+	}
+	acc := l.doc.Accessors[idx]
+	components, ok := gltfComponents[acc.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d: unsupported type %q", idx, acc.Type)
+	}
+	compSize := gltfComponentSize(acc.ComponentType)
+	if compSize == 0 {
+		return nil, 0, fmt.Errorf("accessor %d: unsupported component type %d", idx, acc.ComponentType)
+	}
+	elemSize := components * compSize
+
+	stride = elemSize
+	if acc.BufferView == nil { // accessors without buffer view contain zeros
+		data = make([]byte, acc.Count*elemSize)
+	} else {
+		if *acc.BufferView >= 0 && *acc.BufferView < len(l.doc.BufferViews) && l.doc.BufferViews[*acc.BufferView].ByteStride > 0 {
+			stride = l.doc.BufferViews[*acc.BufferView].ByteStride
+		}
+		length := 0
+		if acc.Count > 0 {
+			length = (acc.Count-1)*stride + elemSize
+		}
+		if data, err = l.bufferViewData(*acc.BufferView, acc.ByteOffset, length); err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", idx, err)
+		}
+	}
+	if acc.Sparse == nil {
+		return data, stride, nil
+	}
+	if data, err = l.applySparse(idx, data, stride, elemSize); err != nil {
+		return nil, 0, fmt.Errorf("accessor %d: sparse: %w", idx, err)
+	}
+	return data, elemSize, nil
+}
+
+// applySparse returns a tightly packed copy of the accessor's elements, with the sparse values substituted.
+func (l *gltfLoader) applySparse(idx int, dense []byte, stride, elemSize int) ([]byte, error) {
+	acc := l.doc.Accessors[idx]
+	sparse := acc.Sparse
+	if sparse.Count < 0 || sparse.Count > acc.Count {
+		return nil, fmt.Errorf("invalid count %d", sparse.Count)
+	}
+	indexType := sparse.Indices.ComponentType
+	if indexType != gl.UNSIGNED_BYTE && indexType != gl.UNSIGNED_SHORT && indexType != gl.UNSIGNED_INT {
+		return nil, fmt.Errorf("invalid index type %d", indexType)
+	}
+	indices, err := l.bufferViewData(sparse.Indices.BufferView, sparse.Indices.ByteOffset, sparse.Count*gltfComponentSize(indexType))
+	if err != nil {
+		return nil, fmt.Errorf("indices: %w", err)
+	}
+	values, err := l.bufferViewData(sparse.Values.BufferView, sparse.Values.ByteOffset, sparse.Count*elemSize)
+	if err != nil {
+		return nil, fmt.Errorf("values: %w", err)
+	}
+
+	data := make([]byte, acc.Count*elemSize)
+	for e := 0; e < acc.Count; e++ {
+		copy(data[e*elemSize:(e+1)*elemSize], dense[e*stride:])
+	}
+	for i := 0; i < sparse.Count; i++ {
+		var e int
+		switch indexType {
+		case gl.UNSIGNED_BYTE:
+			e = int(indices[i])
+		case gl.UNSIGNED_SHORT:
+			e = int(binary.LittleEndian.Uint16(indices[i*2:]))
+		case gl.UNSIGNED_INT:
+			e = int(binary.LittleEndian.Uint32(indices[i*4:]))
+		}
+		if e >= acc.Count {
+			return nil, fmt.Errorf("index %d out of range", e)
+		}
+		copy(data[e*elemSize:(e+1)*elemSize], values[i*elemSize:])
+	}
+	return data, nil
+}
+
+// bufferViewData returns length bytes of a buffer view, starting at the given offset within the view.
+func (l *gltfLoader) bufferViewData(idx, offset, length int) ([]byte, error) {
+	if idx < 0 || idx >= len(l.doc.BufferViews) {
+		return nil, fmt.Errorf("buffer view %d does not exist", idx)
+	}
+	view := l.doc.BufferViews[idx]
+	if view.Buffer < 0 || view.Buffer >= len(l.buffers) {
+		return nil, fmt.Errorf("buffer %d does not exist", view.Buffer)
+	}
+	start := view.ByteOffset + offset
+	end := start + length
+	if offset < 0 || length < 0 || start < 0 || end > view.ByteOffset+view.ByteLength || end > len(l.buffers[view.Buffer]) {
+		return nil, fmt.Errorf("buffer view %d out of range", idx)
+	}
+	return l.buffers[view.Buffer][start:end], nil
+}
+
+// readFloats reads an accessor with the given number of components as floats.
+// Integer components are converted, respecting the accessor's normalization.
+func (l *gltfLoader) readFloats(idx, components int) ([]float32, error) {
+	data, stride, err := l.accessorData(idx)
+	if err != nil {
+		return nil, err
+	}
+	acc := l.doc.Accessors[idx]
+	if gltfComponents[acc.Type] != components {
+		return nil, fmt.Errorf("accessor %d: expected %d components, got %s", idx, components, acc.Type)
+	}
+
+	compSize := gltfComponentSize(acc.ComponentType)
+	values := make([]float32, acc.Count*components)
+	for e := 0; e < acc.Count; e++ {
+		for c := 0; c < components; c++ {
+			values[e*components+c] = readComponent(data[e*stride+c*compSize:], acc.ComponentType, acc.Normalized)
+		}
+	}
+	return values, nil
+}
+
+func (l *gltfLoader) readIndices(idx, vertexCount int) ([]uint32, error) {
+	data, stride, err := l.accessorData(idx)
+	if err != nil {
+		return nil, err
+	}
+	acc := l.doc.Accessors[idx]
+	if acc.Type != "SCALAR" {
+		return nil, fmt.Errorf("accessor %d: indices must be scalars", idx)
+	}
+
+	indices := make([]uint32, acc.Count)
+	for i := range indices {
+		switch acc.ComponentType {
+		case gl.UNSIGNED_BYTE:
+			indices[i] = uint32(data[i*stride])
+		case gl.UNSIGNED_SHORT:
+			indices[i] = uint32(binary.LittleEndian.Uint16(data[i*stride:]))
+		case gl.UNSIGNED_INT:
+			indices[i] = binary.LittleEndian.Uint32(data[i*stride:])
+		default:
+			return nil, fmt.Errorf("accessor %d: invalid index type %d", idx, acc.ComponentType)
+		}
+		if int(indices[i]) >= vertexCount {
+			return nil, fmt.Errorf("accessor %d: index %d out of range", idx, indices[i])
+		}
+	}
+	return indices, nil
+}
+
+func gltfComponentSize(componentType gl.Enum) int {
+	switch componentType {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT:
+		return 2
+	case gl.UNSIGNED_INT, gl.FLOAT:
+		return 4
+	}
+	return 0
+}
+
+func readComponent(data []byte, componentType gl.Enum, normalized bool) float32 {
+	var value, max float32
+	switch componentType {
+	case gl.FLOAT:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	case gl.BYTE:
+		value, max = float32(int8(data[0])), math.MaxInt8
+	case gl.UNSIGNED_BYTE:
+		value, max = float32(data[0]), math.MaxUint8
+	case gl.SHORT:
+		value, max = float32(int16(binary.LittleEndian.Uint16(data))), math.MaxInt16
+	case gl.UNSIGNED_SHORT:
+		value, max = float32(binary.LittleEndian.Uint16(data)), math.MaxUint16
+	case gl.UNSIGNED_INT:
+		value, max = float32(binary.LittleEndian.Uint32(data)), math.MaxUint32
+	}
+	if !normalized {
+		return value
+	}
+	return float32(math.Max(float64(value/max), -1))
+}
+
+// vertexNormals calculates smooth vertex normals by averaging the normals of all adjacent triangles.
+// Primitives other than triangles get normals facing +z.
+func vertexNormals(positions []float32, indices []uint32, primitiveType nora.PrimitiveType) []float32 {
+	vertexCount := len(positions) / 3
+	normals := make([]vmath.Vec3f, vertexCount)
+	if primitiveType == gl.TRIANGLES {
+		index := func(i int) int { return i }
+		count := vertexCount
+		if indices != nil {
+			index = func(i int) int { return int(indices[i]) }
+			count = len(indices)
+		}
+		pos := func(v int) vmath.Vec3f { return vmath.Vec3f{positions[v*3], positions[v*3+1], positions[v*3+2]} }
+		for i := 0; i+2 < count; i += 3 {
+			a, b, c := index(i), index(i+1), index(i+2)
+			normal := pos(b).Sub(pos(a)).Cross(pos(c).Sub(pos(a))) // weighted by the triangle's area
+			normals[a] = normals[a].Add(normal)
+			normals[b] = normals[b].Add(normal)
+			normals[c] = normals[c].Add(normal)
+		}
+	}
+
+	result := make([]float32, 0, vertexCount*3)
+	for _, n := range normals {
+		if n.Length() == 0 {
+			n = vmath.Vec3f{0, 0, 1}
+		}
+		n = n.Normalize()
+		result = append(result, n[0], n[1], n[2])
+	}
+	return result
+}
+
+// loadMaterial creates a material based on the glTF material's base color.
+// Textures are only applied if the geometry has texture coordinates.
+func (l *gltfLoader) loadMaterial(idx *int, texCoords bool) (mat *nora.Material, textured bool, err error) {
+	if idx == nil { // default material
+		mat = nora.NewMaterial(shader.COL_NORM_3D)
+		mat.Uniform4fColor("fragColor", color.White)
+		return mat, false, nil
+	}
+	if *idx < 0 || *idx >= len(l.doc.Materials) {
+		return nil, false, fmt.Errorf("material %d does not exist", *idx)
+	}
+	m := l.doc.Materials[*idx]
+
+	baseColor := color.White
+	if f := m.PBR.BaseColorFactor; len(f) == 4 {
+		baseColor = color.Color{R: f[0], G: f[1], B: f[2], A: f[3]}
+	}
+
+	textured = m.PBR.BaseColorTexture != nil && texCoords
+	if textured {
+		texKey, err := l.loadTexture(m.PBR.BaseColorTexture.Index)
+		if err != nil {
+			return nil, false, fmt.Errorf("material %d: %w", *idx, err)
+		}
+		mat = nora.NewMaterial(shader.COL_TEX_NORM_3D)
+		mat.AddTextureBinding("sampler", texKey)
+	} else {
+		mat = nora.NewMaterial(shader.COL_NORM_3D)
+	}
+	mat.Uniform4fColor("fragColor", baseColor)
+	mat.SetTransparent(m.AlphaMode == "BLEND")
+	return mat, textured, nil
+}
+
+func (l *gltfLoader) loadTexture(idx int) (nora.TextureKey, error) {
+	if idx < 0 || idx >= len(l.doc.Textures) {
+		return "", fmt.Errorf("texture %d does not exist", idx)
+	}
+	tex := l.doc.Textures[idx]
+	if tex.Source == nil || *tex.Source < 0 || *tex.Source >= len(l.doc.Images) {
+		return "", fmt.Errorf("texture %d: missing image", idx)
+	}
+
+	properties := defaultTextureProperties
+	if tex.Sampler != nil && *tex.Sampler >= 0 && *tex.Sampler < len(l.doc.Samplers) {
+		s := l.doc.Samplers[*tex.Sampler]
+		for dst, src := range map[*gl.Enum]gl.Enum{
+			&properties.MagFilter: s.MagFilter,
+			&properties.MinFilter: s.MinFilter,
+			&properties.WrapS:     s.WrapS,
+			&properties.WrapT:     s.WrapT,
+		} {
+			if src != 0 {
+				*dst = src
+			}
+		}
+	}
+
+	img := l.doc.Images[*tex.Source]
+	if img.URI != "" && !strings.HasPrefix(img.URI, "data:") {
+		return l.resources.loadTexture(l.filePath(img.URI), properties)
+	}
+
+	// embedded image
+	var data []byte
+	var err error
+	if img.URI != "" {
+		data, err = l.readURI(img.URI)
+	} else if img.BufferView != nil && *img.BufferView >= 0 && *img.BufferView < len(l.doc.BufferViews) {
+		view := l.doc.BufferViews[*img.BufferView]
+		if view.Buffer < 0 || view.Buffer >= len(l.buffers) || view.ByteOffset+view.ByteLength > len(l.buffers[view.Buffer]) {
+			return "", fmt.Errorf("image %d out of range", *tex.Source)
+		}
+		data = l.buffers[view.Buffer][view.ByteOffset : view.ByteOffset+view.ByteLength]
+	} else {
+		err = errors.New("missing image data")
+	}
+	if err != nil {
+		return "", fmt.Errorf("image %d: %w", *tex.Source, err)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image %d: %w", *tex.Source, err)
+	}
+
+	texKey := l.resources.textureKey(fmt.Sprintf("%s#image%d", filepath.Clean(l.path), *tex.Source))
+	if err := l.resources.loadImage(texKey, decoded, properties); err != nil {
+		return "", err
+	}
+	return texKey, nil
+}
+
+// removeGeometryTexCoords removes the texture coordinates of (position, normal, texCoord) geometry.
+func removeGeometryTexCoords(geom *nora.Geometry) *nora.Geometry {
+	trimmed := nora.NewGeometry32(geom.VertexCount(), removeTexCoords(geom.Vertices()), geom.Indices(), geom.PrimitiveType(), []string{"position", "normal"}, nora.InterleavedBuffer)
+	trimmed.SetAttributeComponents(3, 3)
+	return trimmed
+}
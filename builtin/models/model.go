@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora"
+	"go.uber.org/atomic"
+)
+
+// Model is a 3D model loaded from a file.
+// Models can contain child models (nodes) with their own transformation, that are drawn relative to their parent.
+type Model struct {
+	nora.Transform
+	name     string
+	meshes   []*nora.Mesh
+	children []*Model
+
+	resources *modelResources // nil for child models
+}
+
+// modelResources contains all GPU resources of a model hierarchy.
+// Meshes can be shared between multiple child models.
+type modelResources struct {
+	id           uint32 // unique per loaded model; prevents texture key collisions if the same file is loaded multiple times
+	meshes       []*nora.Mesh
+	textureStore *nora.TextureStore
+	textures     []nora.TextureKey
+}
+
+var modelIDSeq atomic.Uint32
+
+var defaultTextureProperties = nora.TextureProperties{
+	MinFilter: gl.LINEAR,
+	MagFilter: gl.LINEAR,
+	WrapS:     gl.REPEAT,
+	WrapT:     gl.REPEAT,
+}
+
+func newModel(textureStore *nora.TextureStore) *Model {
+	m := newChildModel("")
+	m.resources = &modelResources{
+		id:           modelIDSeq.Inc(),
+		textureStore: textureStore,
+	}
+	return m
+}
+
+func newChildModel(name string) *Model {
+	m := &Model{
+		name: name,
+	}
+	m.ClearTransform()
+	return m
+}
+
+// Name returns the model's name, as defined by the file; empty if there is none.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// Meshes returns all meshes of the model, without the meshes of child models.
+func (m *Model) Meshes() []*nora.Mesh {
+	return m.meshes
+}
+
+// Children returns all child models.
+func (m *Model) Children() []*Model {
+	return m.children
+}
+
+// Find returns the first model within the hierarchy with the given name; nil if there is none.
+func (m *Model) Find(name string) *Model {
+	if m.name == name {
+		return m
+	}
+	for _, child := range m.children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Bounds returns the bounding box of all meshes, including child models, relative to the model's parent.
+// Returns false if no mesh has known bounds.
+func (m *Model) Bounds() (nora.BoundingBox, bool) {
+	var bounds nora.BoundingBox
+	found := false
+	add := func(b nora.BoundingBox) {
+		if found {
+			bounds = bounds.Union(b)
+		} else {
+			bounds, found = b, true
+		}
+	}
+	for _, mesh := range m.meshes {
+		if b, ok := mesh.Bounds(); ok {
+			add(b)
+		}
+	}
+	for _, child := range m.children {
+		if b, ok := child.Bounds(); ok {
+			add(b)
+		}
+	}
+	if !found {
+		return nora.BoundingBox{}, false
+	}
+	return bounds.Transform(m.GetTransform()), true
+}
+
+// Destroy deletes all meshes and unloads the textures of the model and its children.
+// Must be called on the loaded (root) model.
+func (m *Model) Destroy() {
+	res := m.resources
+	if res == nil {
+		return
+	}
+	for _, mesh := range res.meshes {
+		mesh.Destroy()
+	}
+	for _, texKey := range res.textures {
+		res.textureStore.Unload(texKey)
+	}
+	m.resources = &modelResources{id: res.id, textureStore: res.textureStore}
+	m.meshes, m.children = nil, nil
+}
+
+func (m *Model) Draw(renderState *nora.RenderState) {
+	renderState.TransformStack.PushMulRight(m.GetTransform())
+	for _, mesh := range m.meshes {
+		mesh.Draw(renderState)
+	}
+	for _, child := range m.children {
+		child.Draw(renderState)
+	}
+	renderState.TransformStack.Pop()
+}
+
+// newMesh creates a mesh with the given geometry and material.
+func (r *modelResources) newMesh(geom *nora.Geometry, mat *nora.Material) *nora.Mesh {
+	mesh := nora.NewMesh(mat)
+	mesh.SetGeometry(geom)
+	r.meshes = append(r.meshes, mesh)
+	return mesh
+}
+
+// loadTexture loads a texture from the filesystem, if it's not used by the model already.
+// Textures are not shared between loaded models, so that destroying one model doesn't affect others.
+func (r *modelResources) loadTexture(path string, properties nora.TextureProperties) (nora.TextureKey, error) {
+	texKey := r.textureKey(filepath.Clean(path))
+	if r.hasTexture(texKey) {
+		return texKey, nil
+	}
+
+	_, err := r.textureStore.Load(texKey, &nora.TextureDefinition{
+		Path:       path,
+		Properties: properties,
+	})
+	if err != nil {
+		return "", fmt.Errorf("load texture %q: %w", path, err)
+	}
+	r.textures = append(r.textures, texKey)
+	return texKey, nil
+}
+
+// loadImage loads an embedded image as texture.
+func (r *modelResources) loadImage(texKey nora.TextureKey, img image.Image, properties nora.TextureProperties) error {
+	if r.hasTexture(texKey) {
+		return nil
+	}
+	if _, err := r.textureStore.LoadImage(texKey, img, properties); err != nil {
+		return fmt.Errorf("load texture %q: %w", texKey, err)
+	}
+	r.textures = append(r.textures, texKey)
+	return nil
+}
+
+// textureKey returns the key of a texture that is owned by the model.
+func (r *modelResources) textureKey(name string) nora.TextureKey {
+	return nora.TextureKey(fmt.Sprintf("model%d:%s", r.id, name))
+}
+
+func (r *modelResources) hasTexture(texKey nora.TextureKey) bool {
+	for _, key := range r.textures {
+		if key == texKey {
+			return true
+		}
+	}
+	return false
+}
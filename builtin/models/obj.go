@@ -0,0 +1,325 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	_ "image/jpeg" // textures referenced by MTL files
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora"
+	"github.com/maja42/nora/builtin/shader"
+	"github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+	"github.com/sirupsen/logrus"
+)
+
+// objMaterial contains the supported properties of a MTL material.
+type objMaterial struct {
+	diffuse    color.Color
+	diffuseMap string // texture path; empty if there is none
+}
+
+// objGroup contains all faces that use the same material.
+type objGroup struct {
+	material string
+	vertices []float32 // position, normal, texCoord
+	indices  []uint32
+	lookup   map[[3]int]uint32 // deduplicates vertices with the same position/texCoord/normal
+}
+
+// objData contains the parsed content of an OBJ file.
+type objData struct {
+	positions []vmath.Vec3f
+	texCoords []vmath.Vec2f
+	normals   []vmath.Vec3f
+
+	materials map[string]objMaterial
+	groups    []*objGroup
+}
+
+// LoadOBJ loads a Wavefront OBJ file and all referenced MTL materials and textures.
+// Faces are triangulated, missing normals are replaced by face normals.
+// Materials use the builtin COL_TEX_NORM_3D shader, or COL_NORM_3D if they have no texture.
+// Textures are loaded into the given store and unloaded when the model is destroyed.
+func LoadOBJ(path string, textureStore *nora.TextureStore) (*Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	logrus.Infof("Loading OBJ model %q...", filepath.Base(path))
+	data := &objData{
+		materials: make(map[string]objMaterial),
+	}
+	if err := data.parse(file, filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+
+	model := newModel(textureStore)
+	for _, group := range data.groups {
+		if len(group.indices) == 0 {
+			continue
+		}
+		mtl, ok := data.materials[group.material]
+		if !ok {
+			mtl = objMaterial{diffuse: color.White}
+		}
+
+		var mat *nora.Material
+		var geom *nora.Geometry
+		vertexCount := len(group.vertices) / 8
+		if mtl.diffuseMap != "" {
+			texKey, err := model.resources.loadTexture(mtl.diffuseMap, defaultTextureProperties)
+			if err != nil {
+				model.Destroy()
+				return nil, err
+			}
+			mat = nora.NewMaterial(shader.COL_TEX_NORM_3D)
+			mat.AddTextureBinding("sampler", texKey)
+			geom = nora.NewGeometry32(vertexCount, group.vertices, group.indices, gl.TRIANGLES, []string{"position", "normal", "texCoord"}, nora.InterleavedBuffer)
+			geom.SetAttributeComponents(3, 3, 2)
+		} else {
+			mat = nora.NewMaterial(shader.COL_NORM_3D)
+			geom = nora.NewGeometry32(vertexCount, removeTexCoords(group.vertices), group.indices, gl.TRIANGLES, []string{"position", "normal"}, nora.InterleavedBuffer)
+			geom.SetAttributeComponents(3, 3)
+		}
+		mat.Uniform4fColor("fragColor", mtl.diffuse)
+		mat.SetTransparent(mtl.diffuse.A < 1)
+		model.meshes = append(model.meshes, model.resources.newMesh(geom, mat))
+	}
+	logrus.Infof("OBJ model %q: %d vertices, %d meshes", filepath.Base(path), len(data.positions), len(model.meshes))
+	return model, nil
+}
+
+func (d *objData) parse(r io.Reader, dir string) error {
+	group := d.group("")
+
+	scanner := bufio.NewScanner(r)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+		switch args := fields[1:]; fields[0] {
+		case "v":
+			var v []float32
+			if v, err = parseFloats(args, 3); err == nil {
+				d.positions = append(d.positions, vmath.Vec3f{v[0], v[1], v[2]})
+			}
+		case "vt":
+			var v []float32
+			if v, err = parseFloats(args, 1); err == nil {
+				v = append(v, 0)
+				d.texCoords = append(d.texCoords, vmath.Vec2f{v[0], v[1]})
+			}
+		case "vn":
+			var v []float32
+			if v, err = parseFloats(args, 3); err == nil {
+				d.normals = append(d.normals, vmath.Vec3f{v[0], v[1], v[2]})
+			}
+		case "f":
+			err = d.parseFace(group, args)
+		case "usemtl":
+			group = d.group(strings.Join(args, " "))
+		case "mtllib":
+			for _, lib := range args {
+				if err = d.loadMTL(filepath.Join(dir, lib)); err != nil {
+					break
+				}
+			}
+		default: // objects, groups, smoothing groups, ...
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNr, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// group returns the group of faces with the given material.
+func (d *objData) group(material string) *objGroup {
+	for _, g := range d.groups {
+		if g.material == material {
+			return g
+		}
+	}
+	g := &objGroup{
+		material: material,
+		lookup:   make(map[[3]int]uint32),
+	}
+	d.groups = append(d.groups, g)
+	return g
+}
+
+// parseFace adds a polygon (v, v/vt, v//vn or v/vt/vn) as a triangle fan.
+func (d *objData) parseFace(group *objGroup, args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face with %d vertices", len(args))
+	}
+	refs := make([][3]int, len(args)) // position, texCoord, normal; -1 if missing
+	for i, arg := range args {
+		parts := strings.Split(arg, "/")
+		counts := [3]int{len(d.positions), len(d.texCoords), len(d.normals)}
+		for c := range refs[i] {
+			refs[i][c] = -1
+			if c >= len(parts) || parts[c] == "" {
+				continue
+			}
+			idx, err := strconv.Atoi(parts[c])
+			if err != nil {
+				return fmt.Errorf("invalid face vertex %q: %w", arg, err)
+			}
+			if idx < 0 { // relative to the end
+				idx += counts[c] + 1
+			}
+			if idx < 1 || idx > counts[c] {
+				return fmt.Errorf("face vertex %q out of range", arg)
+			}
+			refs[i][c] = idx - 1
+		}
+		if refs[i][0] < 0 {
+			return fmt.Errorf("face vertex %q without position", arg)
+		}
+	}
+
+	for i := 1; i+1 < len(refs); i++ {
+		tri := [3][3]int{refs[0], refs[i], refs[i+1]}
+		faceNormal := d.faceNormal(tri)
+		for _, ref := range tri {
+			group.indices = append(group.indices, d.vertex(group, ref, faceNormal))
+		}
+	}
+	return nil
+}
+
+// vertex returns the index of the referenced vertex within the group; the vertex is added if needed.
+func (d *objData) vertex(group *objGroup, ref [3]int, faceNormal vmath.Vec3f) uint32 {
+	if ref[2] >= 0 {
+		if idx, ok := group.lookup[ref]; ok {
+			return idx
+		}
+	}
+
+	pos := d.positions[ref[0]]
+	normal := faceNormal
+	if ref[2] >= 0 {
+		normal = d.normals[ref[2]]
+	}
+	var texCoord vmath.Vec2f
+	if ref[1] >= 0 {
+		texCoord = d.texCoords[ref[1]]
+	}
+
+	idx := uint32(len(group.vertices) / 8)
+	group.vertices = append(group.vertices,
+		pos[0], pos[1], pos[2],
+		normal[0], normal[1], normal[2],
+		texCoord[0], texCoord[1])
+	if ref[2] >= 0 { // vertices with face normals can't be shared
+		group.lookup[ref] = idx
+	}
+	return idx
+}
+
+// faceNormal calculates the normal of a counter-clockwise triangle.
+func (d *objData) faceNormal(tri [3][3]int) vmath.Vec3f {
+	a, b, c := d.positions[tri[0][0]], d.positions[tri[1][0]], d.positions[tri[2][0]]
+	normal := b.Sub(a).Cross(c.Sub(a))
+	if normal.Length() == 0 {
+		return vmath.Vec3f{0, 0, 1}
+	}
+	return normal.Normalize()
+}
+
+// loadMTL parses a material library.
+// Supports diffuse colors (Kd), transparency (d, Tr) and diffuse textures (map_Kd).
+func (d *objData) loadMTL(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var name string
+	mtl := objMaterial{diffuse: color.White}
+	scanner := bufio.NewScanner(file)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var v []float32
+		switch args := fields[1:]; fields[0] {
+		case "newmtl":
+			if name != "" {
+				d.materials[name] = mtl
+			}
+			name = strings.Join(args, " ")
+			mtl = objMaterial{diffuse: color.White}
+		case "Kd":
+			if v, err = parseFloats(args, 3); err == nil {
+				mtl.diffuse.R, mtl.diffuse.G, mtl.diffuse.B = v[0], v[1], v[2]
+			}
+		case "d":
+			if v, err = parseFloats(args, 1); err == nil {
+				mtl.diffuse.A = v[0]
+			}
+		case "Tr":
+			if v, err = parseFloats(args, 1); err == nil {
+				mtl.diffuse.A = 1 - v[0]
+			}
+		case "map_Kd":
+			if len(args) == 0 {
+				err = fmt.Errorf("missing texture path")
+				break
+			}
+			// options (like "-s 1 1 1") precede the file name
+			mtl.diffuseMap = filepath.Join(filepath.Dir(path), args[len(args)-1])
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filepath.Base(path), lineNr, err)
+		}
+	}
+	if name != "" {
+		d.materials[name] = mtl
+	}
+	return scanner.Err()
+}
+
+// parseFloats parses at least min float values.
+func parseFloats(args []string, min int) ([]float32, error) {
+	if len(args) < min {
+		return nil, fmt.Errorf("expected %d values, got %d", min, len(args))
+	}
+	values := make([]float32, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid value %q", arg)
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+// removeTexCoords converts interleaved (position, normal, texCoord) vertices into (position, normal) vertices.
+func removeTexCoords(vertices []float32) []float32 {
+	result := make([]float32, 0, len(vertices)/8*6)
+	for v := 0; v+8 <= len(vertices); v += 8 {
+		result = append(result, vertices[v:v+6]...)
+	}
+	return result
+}
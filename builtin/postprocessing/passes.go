@@ -0,0 +1,81 @@
+// Package postprocessing provides materials for the builtin post-processing passes.
+// The returned passes can be added to the engine's post-processing chain and configured further via their uniforms.
+// Requires the builtin shaders to be loaded.
+package postprocessing
+
+import (
+	"github.com/maja42/nora"
+	"github.com/maja42/nora/builtin/shader"
+	"github.com/maja42/nora/color"
+)
+
+// Copy returns a pass that outputs its input without modification.
+func Copy() *nora.Material {
+	return nora.NewMaterial(shader.POST_COPY)
+}
+
+// Blur returns a pass that applies a gaussian blur along the given direction (in texels).
+// Use two passes with the directions (1, 0) and (0, 1) for blurring the whole image.
+func Blur(dirX, dirY float32) *nora.Material {
+	mat := nora.NewMaterial(shader.POST_BLUR)
+	mat.Uniform2f("direction", dirX, dirY)
+	return mat
+}
+
+// Threshold returns a pass that discards all pixels darker than the given threshold [0, 1].
+func Threshold(threshold float32) *nora.Material {
+	mat := nora.NewMaterial(shader.POST_THRESHOLD)
+	mat.Uniform1f("threshold", threshold)
+	return mat
+}
+
+// Bloom returns all passes that are needed for a bloom effect.
+// Bright areas are extracted, blurred and added to the original scene.
+//	- threshold 	Minimum brightness [0, 1] of glowing pixels
+//	- spread 		Blur distance in texels
+//	- intensity 	Strength of the glow
+func Bloom(threshold, spread, intensity float32) []*nora.Material {
+	combine := nora.NewMaterial(shader.POST_BLOOM)
+	combine.AddTextureBinding("scene", nora.PostProcessSceneTextureKey)
+	combine.Uniform1f("intensity", intensity)
+
+	return []*nora.Material{
+		Threshold(threshold),
+		Blur(spread, 0),
+		Blur(0, spread),
+		combine,
+	}
+}
+
+// ColorGrading returns a pass that adjusts the colors of the image.
+// The default values (0, 1, 1, white) do not modify the image.
+func ColorGrading(brightness, contrast, saturation float32, tint color.Color) *nora.Material {
+	mat := nora.NewMaterial(shader.POST_GRADING)
+	mat.Uniform1f("brightness", brightness)
+	mat.Uniform1f("contrast", contrast)
+	mat.Uniform1f("saturation", saturation)
+	mat.Uniform4fColor("tint", tint)
+	return mat
+}
+
+// Vignette returns a pass that darkens the image towards its edges.
+//	- radius 		Distance from the center where darkening starts; 0.5 reaches the screen edge
+//	- softness 		Width of the transition
+//	- intensity 	Strength of the darkening [0, 1]
+func Vignette(radius, softness, intensity float32) *nora.Material {
+	mat := nora.NewMaterial(shader.POST_VIGNETTE)
+	mat.Uniform1f("radius", radius)
+	mat.Uniform1f("softness", softness)
+	mat.Uniform1f("intensity", intensity)
+	return mat
+}
+
+// CRT returns a pass that imitates an old cathode-ray tube monitor with a curved screen and scanlines.
+//	- curvature 	Screen bending; 0 = flat
+//	- scanlines 	Scanline intensity [0, 1]
+func CRT(curvature, scanlines float32) *nora.Material {
+	mat := nora.NewMaterial(shader.POST_CRT)
+	mat.Uniform1f("curvature", curvature)
+	mat.Uniform1f("scanlines", scanlines)
+	return mat
+}
@@ -17,6 +17,15 @@ const (
 	COL_3D          nora.ShaderProgKey = "col-3D"
 	COL_NORM_3D     nora.ShaderProgKey = "col-norm-3D"
 	COL_TEX_NORM_3D nora.ShaderProgKey = "col-tex-norm-3D"
+
+	// Post-processing passes
+	POST_COPY      nora.ShaderProgKey = "post-copy"
+	POST_BLUR      nora.ShaderProgKey = "post-blur"
+	POST_THRESHOLD nora.ShaderProgKey = "post-threshold"
+	POST_BLOOM     nora.ShaderProgKey = "post-bloom"
+	POST_GRADING   nora.ShaderProgKey = "post-grading"
+	POST_VIGNETTE  nora.ShaderProgKey = "post-vignette"
+	POST_CRT       nora.ShaderProgKey = "post-crt"
 )
 
 // Builtins returns all built-in shader programs
@@ -69,5 +78,33 @@ var Builtins = func(shaderLocation string) map[nora.ShaderProgKey]nora.ShaderPro
 			VertexShaderPath:   shaderLocation + "3d-col-tex-norm.vs.glsl",
 			FragmentShaderPath: shaderLocation + "rgba-tex.fs.glsl",
 		},
+		POST_COPY: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-copy.fs.glsl",
+		},
+		POST_BLUR: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-blur.fs.glsl",
+		},
+		POST_THRESHOLD: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-threshold.fs.glsl",
+		},
+		POST_BLOOM: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-bloom.fs.glsl",
+		},
+		POST_GRADING: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-grading.fs.glsl",
+		},
+		POST_VIGNETTE: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-vignette.fs.glsl",
+		},
+		POST_CRT: {
+			VertexShaderPath:   shaderLocation + "post.vs.glsl",
+			FragmentShaderPath: shaderLocation + "post-crt.fs.glsl",
+		},
 	}
 }
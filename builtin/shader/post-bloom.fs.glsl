@@ -0,0 +1,16 @@
+precision mediump float;
+
+uniform sampler2D sampler;  // blurred highlights
+uniform sampler2D scene;    // original scene
+uniform float intensity;
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	vec2 uv = vec2(vTexCoord.s, 1.0 - vTexCoord.t);
+	vec4 sceneColor = texture2D(scene, uv);
+	vec4 bloomColor = texture2D(sampler, uv);
+
+	gl_FragColor = vec4(sceneColor.rgb + bloomColor.rgb * intensity, sceneColor.a);
+}
\ No newline at end of file
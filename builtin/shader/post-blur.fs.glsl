@@ -0,0 +1,22 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+uniform vec2 texelSize;     // 1 / texture size
+uniform vec2 direction;     // blur direction in texels, eg. (1, 0) for horizontal blurring
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	vec2 uv = vec2(vTexCoord.s, 1.0 - vTexCoord.t);
+	vec2 offset = direction * texelSize;
+
+	// 9-tap gaussian blur, using linear sampling between texels
+	vec4 color = texture2D(sampler, uv) * 0.2270270270;
+	color += texture2D(sampler, uv + offset * 1.3846153846) * 0.3162162162;
+	color += texture2D(sampler, uv - offset * 1.3846153846) * 0.3162162162;
+	color += texture2D(sampler, uv + offset * 3.2307692308) * 0.0702702703;
+	color += texture2D(sampler, uv - offset * 3.2307692308) * 0.0702702703;
+
+	gl_FragColor = color;
+}
\ No newline at end of file
@@ -0,0 +1,10 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	gl_FragColor = texture2D(sampler, vec2(vTexCoord.s, 1.0 - vTexCoord.t));
+}
\ No newline at end of file
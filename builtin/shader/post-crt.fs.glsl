@@ -0,0 +1,29 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+uniform vec2 texelSize;     // 1 / texture size
+uniform float curvature;    // screen bending; 0 = flat
+uniform float scanlines;    // scanline intensity [0, 1]
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// bend the screen
+	vec2 centered = vTexCoord * 2.0 - 1.0;
+	centered *= 1.0 + curvature * dot(centered.yx, centered.yx);
+	vec2 uv = centered * 0.5 + 0.5;
+
+	if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+		gl_FragColor = vec4(0.0, 0.0, 0.0, 1.0);
+		return;
+	}
+
+	// render targets have their origin in the top-left corner.
+	vec4 color = texture2D(sampler, vec2(uv.s, 1.0 - uv.t));
+
+	// one scanline every two pixels
+	float line = sin(uv.y / texelSize.y * 3.14159265);
+	color.rgb *= 1.0 - scanlines * (0.5 - 0.5 * line);
+
+	gl_FragColor = color;
+}
\ No newline at end of file
@@ -0,0 +1,22 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+uniform float brightness;   // additive; 0 = unmodified
+uniform float contrast;     // multiplicative; 1 = unmodified
+uniform float saturation;   // multiplicative; 1 = unmodified
+uniform vec4 tint;          // multiplicative; white = unmodified
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	vec4 color = texture2D(sampler, vec2(vTexCoord.s, 1.0 - vTexCoord.t));
+
+	vec3 rgb = color.rgb + brightness;
+	rgb = (rgb - 0.5) * contrast + 0.5;
+
+	float luminance = dot(rgb, vec3(0.2126, 0.7152, 0.0722));
+	rgb = mix(vec3(luminance), rgb, saturation);
+
+	gl_FragColor = vec4(clamp(rgb, 0.0, 1.0), color.a) * tint;
+}
\ No newline at end of file
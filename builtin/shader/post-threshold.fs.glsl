@@ -0,0 +1,17 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+uniform float threshold;    // minimum brightness [0, 1] of pixels that are kept
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	vec4 color = texture2D(sampler, vec2(vTexCoord.s, 1.0 - vTexCoord.t));
+	float brightness = dot(color.rgb, vec3(0.2126, 0.7152, 0.0722));
+
+	if (brightness < threshold) {
+		color.rgb = vec3(0.0);
+	}
+	gl_FragColor = color;
+}
\ No newline at end of file
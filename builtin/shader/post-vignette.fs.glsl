@@ -0,0 +1,18 @@
+precision mediump float;
+
+uniform sampler2D sampler;
+uniform float radius;       // distance from the center where darkening starts; 0.5 = screen edge
+uniform float softness;     // width of the transition
+uniform float intensity;    // [0, 1]
+
+varying vec2 vTexCoord;
+
+void main(void) {
+	// render targets have their origin in the top-left corner.
+	vec4 color = texture2D(sampler, vec2(vTexCoord.s, 1.0 - vTexCoord.t));
+
+	float dist = distance(vTexCoord, vec2(0.5));
+	float vignette = smoothstep(radius, radius - softness, dist);
+
+	gl_FragColor = vec4(color.rgb * mix(1.0, vignette, intensity), color.a);
+}
\ No newline at end of file
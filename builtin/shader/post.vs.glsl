@@ -0,0 +1,11 @@
+uniform   mat4 vpMatrix;            // identity; flips the y-axis when rendering into render targets
+
+attribute vec2 position;
+attribute vec2 texCoord;
+
+varying vec2 vTexCoord;
+
+void main(void) {
+    gl_Position = vpMatrix * vec4(position, 0.0, 1.0);
+    vTexCoord = texCoord;
+}
@@ -35,6 +35,7 @@ func NewLineStrip2D(thickness float32, lineJoint geo2d.LineJoint, loop bool) *Li
 		endCap:   geo2d.FlatLineCap,
 	}
 	s.ClearTransform()
+	s.mesh.SetUsage(nora.StreamBuffer) // the geometry is regenerated on every change
 	s.SetColor(color.White)
 	s.SetProperties(thickness, lineJoint, loop)
 	return s
@@ -6,6 +6,21 @@ import (
 	"github.com/maja42/nora/builtin/shader"
 )
 
+// spriteGeometry is a unit quad (counter-clockwise)
+//
+//	3 - 2
+//	| / |
+//	0 - 1
+var spriteGeometry = nora.NewGeometry(6, []float32{
+	/*xy*/ 0, 0 /*uv*/, 0, 0, // 0
+	/*xy*/ 1, 0 /*uv*/, 1, 0, // 1
+	/*xy*/ 1, 1 /*uv*/, 1, 1, // 2
+
+	/*xy*/ 1, 1 /*uv*/, 1, 1, // 2
+	/*xy*/ 0, 1 /*uv*/, 0, 1, // 3
+	/*xy*/ 0, 0 /*uv*/, 0, 0, // 0
+}, nil, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
+
 type Sprite struct {
 	nora.Transform
 	mesh nora.Mesh
@@ -21,22 +36,7 @@ func NewSprite() *Sprite {
 	}
 	s.ClearTransform()
 
-	/* counter-clockwise
-	   3 - 2
-	   | / |
-	   0 - 1
-	*/
-
-	vertices := []float32{
-		/*xy*/ 0, 0 /*uv*/, 0, 0, // 0
-		/*xy*/ 1, 0 /*uv*/, 1, 0, // 1
-		/*xy*/ 1, 1 /*uv*/, 1, 1, // 2
-
-		/*xy*/ 1, 1 /*uv*/, 1, 1, // 2
-		/*xy*/ 0, 1 /*uv*/, 0, 1, // 3
-		/*xy*/ 0, 0 /*uv*/, 0, 0, // 0
-	}
-	s.mesh.SetVertexData(6, vertices, nil, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
+	s.mesh.SetGeometry(spriteGeometry)
 	return s
 }
 
@@ -44,6 +44,17 @@ func (m *Sprite) SetTexture(texKey nora.TextureKey) {
 	m.mesh.Material().AddTextureBinding("sampler", texKey)
 }
 
+// Material returns the sprite's material.
+func (m *Sprite) Material() *nora.Material {
+	return m.mesh.Material()
+}
+
+// SetMaterial replaces the sprite's material.
+// Sprites sharing the same material can be drawn with a single draw call (see DrawBatched).
+func (m *Sprite) SetMaterial(mat *nora.Material) {
+	m.mesh.SetMaterial(mat)
+}
+
 func (m *Sprite) Destroy() {
 	m.mesh.Destroy()
 }
@@ -53,3 +64,10 @@ func (m *Sprite) Draw(renderState *nora.RenderState) {
 	m.mesh.Draw(renderState)
 	renderState.TransformStack.Pop()
 }
+
+// DrawBatched adds the sprite to the batch instead of drawing it immediately.
+func (m *Sprite) DrawBatched(renderState *nora.RenderState, batch *nora.Batch) {
+	renderState.TransformStack.PushMulRight(m.GetTransform())
+	batch.Add(renderState, m.mesh.Material(), spriteGeometry)
+	renderState.TransformStack.Pop()
+}
@@ -39,6 +39,7 @@ func NewTerminal(font *nora.Font, size vmath.Vec2i, lineSpacing float32) *Termin
 		size:        size,
 	}
 	t.ClearTransform()
+	t.mesh.SetUsage(nora.DynamicBuffer) // SetRune modifies individual characters
 
 	// font characters have unique offsets and dimensions, they are not "blocked".
 	// therefore, every character needs vertices with unique offsets and texture coordinates.
@@ -55,14 +56,13 @@ func NewTerminal(font *nora.Font, size vmath.Vec2i, lineSpacing float32) *Termin
 	primitives := characters * 2
 
 	vertices := make([]float32, vertexCnt*4) // x,y,u,v
-	indices := make([]uint16, primitives*3)
-	assert.True(len(indices) <= 0xFFFF, "terminal too big for 16bit indices")
+	indices := make([]uint32, primitives*3)
 
 	idx := 0
 	for y := 0; y < size[1]; y++ {
 		for x := 0; x < size[0]; x++ {
-			vIdx := t.vtxIndex(vmath.Vec2i{x, y})
-			copy(indices[idx:], []uint16{
+			vIdx := uint32(t.vtxIndex(vmath.Vec2i{x, y}))
+			copy(indices[idx:], []uint32{
 				vIdx, vIdx + 1, vIdx + 2,
 				vIdx + 2, vIdx + 3, vIdx,
 			})
@@ -72,7 +72,7 @@ func NewTerminal(font *nora.Font, size vmath.Vec2i, lineSpacing float32) *Termin
 
 	t.text = make([]rune, characters)
 
-	t.mesh.SetVertexData(vertexCnt, vertices, indices, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
+	t.mesh.SetVertexData32(vertexCnt, vertices, indices, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
 	return t
 }
 
@@ -87,12 +87,12 @@ func (t *Terminal) Size() vmath.Vec2i {
 }
 
 // vtxIndex returns the vertex index for the given character position. Ignores vertex components/size
-func (t *Terminal) vtxIndex(pos vmath.Vec2i) uint16 {
+func (t *Terminal) vtxIndex(pos vmath.Vec2i) int {
 	verticesPerChar := 4
 	posIdx := pos[0] + pos[1]*t.size[0]
 	assert.True(posIdx < t.size[0]*t.size[1], "posIdx out-of-range: %d <> %v in %v", posIdx, pos, t.size)
 
-	return uint16(posIdx * verticesPerChar)
+	return posIdx * verticesPerChar
 }
 
 // CharPos returns the position of a character in model-space
@@ -126,7 +126,7 @@ func (t *Terminal) SetRune(pos vmath.Vec2i, r rune) {
 		/*xy*/ xr, yt /*uv*/, br[0], tl[1],
 		/*xy*/ xl, yt /*uv*/, tl[0], tl[1],
 	}
-	t.mesh.SetVertexSubData(int(t.vtxIndex(pos)), vtxData)
+	t.mesh.SetVertexSubData(t.vtxIndex(pos), vtxData)
 }
 
 func (t *Terminal) Destroy() {
@@ -66,12 +66,12 @@ func (m *Text) update() {
 	m.bounds.Min[1], m.bounds.Max[1] = float32(f.Ascender)*scale, float32(f.Ascender)*scale // The text's origin is at the baseline. The first line extends upwards.
 
 	vertices := make([]float32, len(m.text)*4*4) // each rune requires 4 vertices; (x, y, u, v) per vertex
-	indices := make([]uint16, len(m.text)*6)     // each rune requires 2 triangles
+	indices := make([]uint32, len(m.text)*6)     // each rune requires 2 triangles
 
 	var origin float32   // X
 	var baseline float32 // Y
 
-	vtx := uint16(0)
+	vtx := uint32(0)
 	idx := 0
 
 	for _, r := range m.text {
@@ -115,7 +115,7 @@ func (m *Text) update() {
 			/*xy*/ xr, yt /*uv*/, br[0], tl[1],
 			/*xy*/ xl, yt /*uv*/, tl[0], tl[1],
 		})
-		copy(indices[idx:], []uint16{
+		copy(indices[idx:], []uint32{
 			vtx, vtx + 1, vtx + 2,
 			vtx + 2, vtx + 3, vtx,
 		})
@@ -135,7 +135,7 @@ func (m *Text) update() {
 
 	vertexCount := int(vtx)
 
-	m.mesh.SetVertexData(vertexCount, vertices, indices, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
+	m.mesh.SetVertexData32(vertexCount, vertices, indices, gl.TRIANGLES, []string{"position", "texCoord"}, nora.InterleavedBuffer)
 }
 
 // Set changes the rendered text.
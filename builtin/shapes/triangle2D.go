@@ -7,6 +7,12 @@ import (
 	"github.com/maja42/nora/color"
 )
 
+var triangle2DGeometry = nora.NewGeometry(3, []float32{
+	/*xy*/ 0, 0,
+	/*xy*/ 1, 0,
+	/*xy*/ 1, 1,
+}, nil, gl.TRIANGLES, []string{"position"}, nora.InterleavedBuffer)
+
 type Triangle2D struct {
 	nora.Transform
 	mesh nora.Mesh
@@ -21,12 +27,7 @@ func NewTriangle2D() *Triangle2D {
 	}
 	s.ClearTransform()
 
-	vertices := []float32{
-		/*xy*/ 0, 0,
-		/*xy*/ 1, 0,
-		/*xy*/ 1, 1,
-	}
-	s.mesh.SetVertexData(3, vertices, nil, gl.TRIANGLES, []string{"position"}, nora.InterleavedBuffer)
+	s.mesh.SetGeometry(triangle2DGeometry)
 	return s
 }
 
@@ -34,6 +35,17 @@ func (m *Triangle2D) Destroy() {
 	m.mesh.Destroy()
 }
 
+// Material returns the triangle's material.
+func (m *Triangle2D) Material() *nora.Material {
+	return m.mesh.Material()
+}
+
+// SetMaterial replaces the triangle's material.
+// Triangles sharing the same material can be drawn with a single draw call (see DrawBatched).
+func (m *Triangle2D) SetMaterial(mat *nora.Material) {
+	m.mesh.SetMaterial(mat)
+}
+
 func (m *Triangle2D) SetColor(c color.Color) {
 	m.mesh.Material().Uniform4fColor("fragColor", c)
 }
@@ -43,3 +55,10 @@ func (m *Triangle2D) Draw(renderState *nora.RenderState) {
 	m.mesh.Draw(renderState)
 	renderState.TransformStack.Pop()
 }
+
+// DrawBatched adds the triangle to the batch instead of drawing it immediately.
+func (m *Triangle2D) DrawBatched(renderState *nora.RenderState, batch *nora.Batch) {
+	renderState.TransformStack.PushMulRight(m.GetTransform())
+	batch.Add(renderState, m.mesh.Material(), triangle2DGeometry)
+	renderState.TransformStack.Pop()
+}
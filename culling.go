@@ -0,0 +1,134 @@
+package nora
+
+import (
+	"math"
+
+	"github.com/maja42/vmath"
+)
+
+// BoundingBox is an axis-aligned box, used for culling objects outside the camera's view.
+type BoundingBox struct {
+	Min, Max vmath.Vec3f
+}
+
+// Corners returns all 8 corners of the box.
+func (b BoundingBox) Corners() [8]vmath.Vec3f {
+	return [8]vmath.Vec3f{
+		{b.Min[0], b.Min[1], b.Min[2]},
+		{b.Max[0], b.Min[1], b.Min[2]},
+		{b.Min[0], b.Max[1], b.Min[2]},
+		{b.Max[0], b.Max[1], b.Min[2]},
+		{b.Min[0], b.Min[1], b.Max[2]},
+		{b.Max[0], b.Min[1], b.Max[2]},
+		{b.Min[0], b.Max[1], b.Max[2]},
+		{b.Max[0], b.Max[1], b.Max[2]},
+	}
+}
+
+// Union returns the smallest box that contains both boxes.
+func (b BoundingBox) Union(other BoundingBox) BoundingBox {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = float32(math.Min(float64(b.Min[i]), float64(other.Min[i])))
+		b.Max[i] = float32(math.Max(float64(b.Max[i]), float64(other.Max[i])))
+	}
+	return b
+}
+
+// Transform returns the axis-aligned box that contains the transformed box.
+func (b BoundingBox) Transform(mat vmath.Mat4f) BoundingBox {
+	corners := b.Corners()
+	first := mat.MulVec(corners[0].Vec4f(1)).XYZ()
+	result := BoundingBox{first, first}
+	for _, c := range corners[1:] {
+		p := mat.MulVec(c.Vec4f(1)).XYZ()
+		result = result.Union(BoundingBox{p, p})
+	}
+	return result
+}
+
+// outsideClipSpace returns true if the transformed box is completely outside the clip volume [-w, +w].
+// Works for orthographic and perspective projections.
+// Boxes that intersect the clip volume's edges without containing any visible area can be reported as visible.
+func (b BoundingBox) outsideClipSpace(mvp vmath.Mat4f) bool {
+	var outside [6]int // number of corners outside each clip plane
+	for _, c := range b.Corners() {
+		p := mvp.MulVec(c.Vec4f(1))
+		for axis := 0; axis < 3; axis++ {
+			if p[axis] < -p[3] {
+				outside[axis*2]++
+			}
+			if p[axis] > p[3] {
+				outside[axis*2+1]++
+			}
+		}
+	}
+	for _, count := range outside {
+		if count == 8 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsVisible returns false if the bounding box (in model space) is completely outside the camera's view,
+// based on the current transformation.
+// Can be used by models to skip drawing objects that are not visible.
+func (r *RenderState) IsVisible(bounds BoundingBox) bool {
+	vp, _ := r.camera.Matrix()
+	return !bounds.outsideClipSpace(vp.Mul(r.TransformStack.Top()))
+}
+
+// culled returns true if the mesh is completely outside the camera's view.
+// Only considers the transformations that are supported by the mesh's shader.
+// Meshes without known bounds are never culled.
+func (r *RenderState) culled(m *Mesh) bool {
+	if !m.hasBounds {
+		return false
+	}
+	sProg, _ := r.shaders.resolve(m.material.sProgKey)
+	if sProg == nil {
+		return false
+	}
+	mvp := vmath.Ident4f()
+	if sProg.vpMatrixLocation.Value >= 0 {
+		mvp, _ = r.camera.Matrix()
+	}
+	if sProg.modelTransformLocation.Value >= 0 {
+		mvp = mvp.Mul(r.TransformStack.Top())
+	}
+	return m.bounds.outsideClipSpace(mvp)
+}
+
+// geometryBounds returns the bounding box of the geometry's "position" attribute.
+// Unknown attribute components of float32 vertex data are taken from the shader.
+// Returns false if the bounds can't be determined.
+func geometryBounds(geom *Geometry, sProgKey ShaderProgKey) (BoundingBox, bool) {
+	if geom.vertexCount == 0 || !geom.hasAttribute("position") {
+		return BoundingBox{}, false
+	}
+	if _, ok := geom.attributeComponents(); !ok && geom.vertexFormat == nil {
+		components := shaderAttributeComponents(sProgKey, geom.vertexAttributes)
+		if components == nil {
+			return BoundingBox{}, false
+		}
+		withComponents := *geom
+		withComponents.components = components
+		geom = &withComponents
+	}
+	min, max := geom.Bounds3D()
+	return BoundingBox{min, max}, true
+}
+
+// shaderAttributeComponents returns the number of components of the given vertex attributes, as expected by the shader.
+// Returns nil if the shader is not loaded.
+func shaderAttributeComponents(sProgKey ShaderProgKey, vertexAttributes []string) []int {
+	sProg, _ := resolveShader(sProgKey)
+	if sProg == nil {
+		return nil
+	}
+	components := make([]int, len(vertexAttributes))
+	for i, attr := range vertexAttributes {
+		components[i] = int(vaTypePropertyMapping[sProg.attributeTypes[attr]].components)
+	}
+	return components
+}
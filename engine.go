@@ -2,6 +2,7 @@ package nora
 
 import (
 	"fmt"
+	"image"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,15 +17,21 @@ import (
 )
 
 // Engine represents the user interface of this library.
-// It's a singleton that can be used to access all functionality.
+// Every engine owns a window with its own OpenGL context, camera and interaction system.
+// Shader programs, textures and buffers are shared between all engines.
 type Engine struct {
 	window            *glfw.Window
+	offscreen         bool // the window is never shown; used for tests and CI rendering
 	windowTitleUpdate time.Time
 	windowTitle       string
 
 	resizePolicy       ResizePolicy
 	desiredAspectRatio float32
 	windowResized      bool
+	framebufferSize    vmath.Vec2i
+	viewport           Viewport // viewport of the default framebuffer
+
+	clearColor color.Color
 
 	rendering sync.Mutex // ensures that only one render-function can be executed at once
 
@@ -32,14 +39,22 @@ type Engine struct {
 	fps         FPSCounter    // measures frame number and fps
 	renderStats atomic.Value  // atomically stores render statistics
 
-	glSync                        // synchronization of OpenGL resources like buffer targets
-	samplerManager samplerManager // manages samplers (=texture targets)
+	captureLock     sync.Mutex
+	captureRequests []chan *image.RGBA // screenshot requests for the next frame
+
+	*glSync                       // synchronization of OpenGL resources like buffer targets; shared between all engines
+	samplerManager samplerManager // manages samplers (=texture targets) of the own context
+
+	created        time.Time   // used for the global "time" uniform
+	uniformSets    uniformSets // uniform sets shared by all shader programs
+	globalUniforms *UniformSet // engine-provided global uniforms; part of uniformSets
 
 	// The following members members must not be overwritten directly:
 	Camera   Camera
-	Shaders  ShaderStore
-	Textures TextureStore
+	Shaders  *ShaderStore  // shared between all engines
+	Textures *TextureStore // shared between all engines
 
+	PostProcessing    PostProcessChain  // fullscreen effects applied to the rendered frame
 	InteractionSystem InteractionSystem // user interaction (mouse, keyboard, ...)
 }
 
@@ -64,10 +79,14 @@ func Init() error {
 // Destroy destroys all remaining windows, frees any allocated resources and de-initializes the OpenGL and GLFW libraries.
 // Stops the render thread afterwards.
 func Destroy() {
-	if engine != nil {
+	engineLock.Lock()
+	remaining := append([]*Engine(nil), engines...)
+	engineLock.Unlock()
+
+	for _, e := range remaining {
 		logrus.Warnf("Engine was not destroyed before shutting down OpenGL.")
 		// if the engine was not destroyed (eg. due to a panic), stop it now
-		engine.Destroy()
+		e.Destroy()
 	}
 	glfw.Terminate()
 	renderThread.Terminate()
@@ -80,22 +99,72 @@ const (
 	ResizeAdjustViewport  = ResizePolicy(iota) // viewport == window size; can lead to distortions
 	ResizeKeepViewport                         // viewport stays the same; can lead to stripped content or unrenderable areas
 	ResizeForbid                               // the window can't be resized
-	ResizeKeepAspectRatio                      // the window will be resized to keep the original aspect ratio
+	ResizeKeepAspectRatio                      // the window will be resized to keep the original aspect ratio; uses black bars if not possible (eg. maximized)
+	ResizeLetterbox                            // the viewport keeps the original aspect ratio and is centered within the window, using black bars
 )
 
-// Currently, only single-window applications are supported.
-//     Multiple windows have multiple contexts. This either requires automatic context-switching, or a context-object
-//     instead of accessing gl-functions directly. --> Find out if multiple contexts/windows can be used simultaneously,
-//     when having multiple renderThreads executed from different OS-threads.
-var engineLock sync.Mutex
-var engine *Engine // For global access
+// Multiple windows have multiple contexts, which are all used by the same render thread.
+// The contexts share all resources (buffers, textures, shader programs), but not their state (eg. bound objects).
+// Before an engine renders, its context is made current. Rendering of different engines is therefore serialized.
+var engineLock sync.Mutex // protects the engine list and the current context
+var engines []*Engine     // all engines, in order of creation
+var engine *Engine        // the engine owning the current context. For global access
+
+// Resources shared by all engines can be accessed from any goroutine, independent of the current context.
+// They are protected by their own lock, because the engineLock is held during rendering.
+var sharedLock sync.RWMutex
+var sharedState *sharedResources // nil if there is no engine
+
+type sharedResources struct {
+	glSync  *glSync
+	shaders *ShaderStore
+}
+
+// sharedBufferSync returns the buffer synchronization of all engines.
+func sharedBufferSync() *glSync {
+	sharedLock.RLock()
+	defer sharedLock.RUnlock()
+	if !iAssertTrue(sharedState != nil, "No engine exists") {
+		return &glSync{}
+	}
+	return sharedState.glSync
+}
+
+// resolveShader returns the shader program with the given key from the shader store shared by all engines.
+// Returns nil if the shader is not loaded, or if there is no engine.
+func resolveShader(key ShaderProgKey) (*shaderProgram, sProgID) {
+	sharedLock.RLock()
+	s := sharedState
+	sharedLock.RUnlock()
+	if s == nil {
+		return nil, sProgID{}
+	}
+	return s.shaders.resolve(key)
+}
 
 // CreateWindow opens a new window and initializes the engine.
 // Must be called after the library is initialized.
 // Call Render() on the returned engine object to start rendering. The window will stay hidden until Render() is called for the first time
 // Call Destroy() to close the window and free resources.
 func CreateWindow(settings Settings) (*Engine, error) {
-	engineLock.Lock() // There can only be one window at a time (context-switching not implemented yet)
+	return createEngine(settings, false)
+}
+
+// CreateOffscreen initializes an engine without a visible window.
+// The engine is backed by a hidden window, which also works with software rasterizers (eg. Mesa llvmpipe).
+// Apart from that, offscreen engines behave like regular ones and can be used for running rendering code in tests or on CI systems.
+// Use RenderFrames() to render a fixed number of frames.
+// Must be called after the library is initialized.
+// Call Destroy() to free resources.
+func CreateOffscreen(settings Settings) (*Engine, error) {
+	settings.ResizePolicy = ResizeForbid
+	settings.Monitor = nil
+	return createEngine(settings, true)
+}
+
+func createEngine(settings Settings, offscreen bool) (*Engine, error) {
+	engineLock.Lock() // the new context becomes current
+	defer engineLock.Unlock()
 
 	resizeable := gl.TRUE
 	if settings.ResizePolicy == ResizeForbid {
@@ -111,15 +180,29 @@ func CreateWindow(settings Settings) (*Engine, error) {
 		settings.WindowSize = vmath.Vec2i{1280, 720} // default resolution
 	}
 
-	window, err := glfw.CreateWindow(settings.WindowSize[0], settings.WindowSize[1], settings.WindowTitle, settings.Monitor, nil)
+	// All engines share their resources with the first one
+	var shared *Engine
+	var sharedWindow *glfw.Window
+	bufferSync := &glSync{}
+	if len(engines) > 0 {
+		shared = engines[0]
+		sharedWindow, bufferSync = shared.window, shared.glSync
+	}
+
+	window, err := glfw.CreateWindow(settings.WindowSize[0], settings.WindowSize[1], settings.WindowTitle, settings.Monitor, sharedWindow)
 	if err != nil {
-		engineLock.Unlock()
 		return nil, err
 	}
-	window.MakeContextCurrent()
-
-	monitor := glfw.GetPrimaryMonitor()
-	vidmode := monitor.GetVideoMode()
+	makeContextCurrent(window, bufferSync)
+
+	refreshRate := 60 // fallback if there is no monitor (eg. headless systems)
+	if monitor := glfw.GetPrimaryMonitor(); monitor != nil {
+		vidmode := monitor.GetVideoMode()
+		refreshRate = vidmode.RefreshRate
+		logrus.Infof("Monitor:          %d x %d @ %dHz (%s)", vidmode.Width, vidmode.Height, vidmode.RefreshRate, monitor.GetName())
+	} else {
+		logrus.Infof("Monitor:          none")
+	}
 
 	var windowSize vmath.Vec2i
 	windowSize[0], windowSize[1] = window.GetSize()
@@ -127,51 +210,86 @@ func CreateWindow(settings Settings) (*Engine, error) {
 	var framebufferSize vmath.Vec2i
 	framebufferSize[0], framebufferSize[1] = window.GetFramebufferSize()
 
-	logrus.Infof("OpenGL version:   %s", gl.GetString(gl.VERSION))
+	glVersion := gl.GetString(gl.VERSION)
+	logrus.Infof("OpenGL version:   %s", glVersion)
 	logrus.Infof("GLSL version:     %s", gl.GetString(gl.SHADING_LANGUAGE_VERSION))
 	logrus.Infof("Vendor:           %s", gl.GetString(gl.VENDOR))
 	logrus.Infof("Renderer:         %s", gl.GetString(gl.RENDERER))
-	logrus.Infof("Monitor:          %d x %d @ %dHz (%s)", vidmode.Width, vidmode.Height, vidmode.RefreshRate, monitor.GetName())
 	logrus.Infof("Window size:      %s", windowSize.Format("%d x %d"))
 	logrus.Infof("Framebuffer size: %s", framebufferSize.Format("%d x %d"))
 	logrus.Infof("")
 
 	cursorX, cursorY := window.GetCursorPos()
 
-	engine = &Engine{
+	n := &Engine{
 		window:             window,
+		offscreen:          offscreen,
 		windowTitle:        settings.WindowTitle,
 		resizePolicy:       settings.ResizePolicy,
 		desiredAspectRatio: float32(settings.WindowSize[0]) / float32(settings.WindowSize[1]),
 
-		vSyncDelay: time.Second / time.Duration(vidmode.RefreshRate),
-		fps:        NewFPSCounter(),
+		vSyncDelay:  time.Second / time.Duration(refreshRate),
+		fps:         NewFPSCounter(),
+		glSync:      bufferSync,
+		created:     time.Now(),
 
-		Camera:   NewOrthoCamera(),
-		Shaders:  newShaderStore(),
-		Textures: newTextureStore(),
+		Camera: NewOrthoCamera(),
 
 		InteractionSystem: newInteractionSystem(windowSize, vmath.Vec2i{int(cursorX), int(cursorY)}),
 	}
+	if shared != nil {
+		n.Shaders, n.Textures = shared.Shaders, shared.Textures
+	} else {
+		n.Shaders, n.Textures = newShaderStore(), newTextureStore()
+		sharedLock.Lock()
+		sharedState = &sharedResources{glSync: bufferSync, shaders: n.Shaders}
+		sharedLock.Unlock()
+	}
+	engine = n
+	engines = append(engines, n)
 
-	engine.configureOpenGL()
+	n.configureOpenGL()
+	n.framebufferSize = framebufferSize
+	n.setViewport(Viewport{0, 0, framebufferSize[0], framebufferSize[1]})
 
-	engine.samplerManager = newSamplerManager(&engine.Textures)
-	engine.renderStats.Store(RenderStats{})
+	n.samplerManager = newSamplerManager(n.Textures)
+	n.renderStats.Store(RenderStats{})
+	n.globalUniforms = newGlobalUniforms()
+	n.uniformSets.add(n.globalUniforms)
 
 	// wire resize configuration
-	engine.Camera.(*OrthoCamera).SetAspectRatio(engine.desiredAspectRatio, engine.desiredAspectRatio > 1)
-	window.SetSizeCallback(engine.resizeCallback)
-	window.SetMaximizeCallback(engine.maximizeCallback)
+	n.Camera.(*OrthoCamera).SetAspectRatio(n.desiredAspectRatio, n.desiredAspectRatio > 1)
+	window.SetSizeCallback(n.resizeCallback)
+	window.SetMaximizeCallback(n.maximizeCallback)
 
 	// wire interaction system
-	window.SetCursorPosCallback(engine.InteractionSystem.cursorPosCallback)
-	window.SetMouseButtonCallback(engine.InteractionSystem.mouseButtonCallback)
-	window.SetScrollCallback(engine.InteractionSystem.scrollCallback)
-	window.SetKeyCallback(engine.InteractionSystem.keyCallback)
+	window.SetCursorPosCallback(n.InteractionSystem.cursorPosCallback)
+	window.SetMouseButtonCallback(n.InteractionSystem.mouseButtonCallback)
+	window.SetScrollCallback(n.InteractionSystem.scrollCallback)
+	window.SetKeyCallback(n.InteractionSystem.keyCallback)
 
 	assert.NoGLError("Engine setup")
-	return engine, nil
+	return n, nil
+}
+
+// makeCurrent activates the engine's OpenGL context.
+// Must be called while holding the engineLock.
+func (n *Engine) makeCurrent() {
+	if engine == n {
+		return
+	}
+	makeContextCurrent(n.window, n.glSync)
+	engine = n
+}
+
+// makeContextCurrent activates the OpenGL context of the given window.
+func makeContextCurrent(window *glfw.Window, bufferSync *glSync) {
+	// Buffer bindings are part of the context. Pending buffer operations must not be split across contexts.
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	window.MakeContextCurrent()
+	bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
 }
 
 func (n *Engine) configureOpenGL() {
@@ -200,15 +318,17 @@ type DrawFrameFunc func(elapsed time.Duration, renderState *RenderState) (stop b
 func (n *Engine) Render(frameFunc DrawFrameFunc) bool {
 	n.rendering.Lock()
 	defer n.rendering.Unlock()
-	n.window.Show()
+	if !n.offscreen {
+		n.window.Show()
+	}
 
 	shouldClose := false
 	for {
-		shouldClose = engine.window.ShouldClose()
+		shouldClose = n.window.ShouldClose()
 		if shouldClose {
 			break
 		}
-		if stop := engine.renderFrame(frameFunc); stop {
+		if stop := n.renderFrame(frameFunc); stop {
 			break
 		}
 	}
@@ -216,38 +336,59 @@ func (n *Engine) Render(frameFunc DrawFrameFunc) bool {
 	return shouldClose
 }
 
+// RenderFrames renders the given number of frames without polling events or waiting for vsync.
+// Stops early if the frameFunc returns 1 (="stop"). Returns the number of rendered frames.
+//
+// The buffers are not swapped, meaning that the result of the last frame stays available for reading (eg. via Screenshot()).
+// This is mainly intended for offscreen engines. Windows of regular engines won't respond while rendering this way.
+func (n *Engine) RenderFrames(frames int, frameFunc DrawFrameFunc) int {
+	n.rendering.Lock()
+	defer n.rendering.Unlock()
+
+	rendered := 0
+	for rendered < frames {
+		engineLock.Lock()
+		n.makeCurrent()
+		stop, renderStats := n.drawFrame(frameFunc)
+		n.fulfillCaptureRequests()
+		engineLock.Unlock()
+
+		n.renderStats.Store(renderStats)
+		rendered++
+		if stop {
+			break
+		}
+	}
+	assert.NoGLError("Render frames end")
+	return rendered
+}
+
 func (n *Engine) renderFrame(frameFunc DrawFrameFunc) bool {
-	frame, elapsed, framerate := n.fps.NextFrame()
-	n.handleResize()
+	engineLock.Lock()
+	n.makeCurrent()
+	stop, renderStats := n.drawFrame(frameFunc)
+	n.fulfillCaptureRequests() // the back buffer is only valid until swapped
+	engineLock.Unlock()
+	frame, framerate := renderStats.Frame, renderStats.Framerate
 
-	if time.Since(n.windowTitleUpdate) >= 100*time.Millisecond {
+	if !n.offscreen && time.Since(n.windowTitleUpdate) >= 100*time.Millisecond {
 		n.window.SetTitle(fmt.Sprintf("%s [%.2ffps]", n.windowTitle, framerate))
 		//n.window.SetTitle(n.windowTitle)
 		n.windowTitleUpdate = time.Now()
 	}
 
-	renderState := newRenderState(n.Camera, &n.Shaders, &n.samplerManager)
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
-	stop := frameFunc(elapsed, renderState)
-	assert.True(renderState.TransformStack.Size() == 1, "Transform stack: not empty after rendering")
-
-	renderStats := RenderStats{
-		Frame:           frame,
-		Framerate:       framerate,
-		TotalDrawCalls:  renderState.totalDrawCalls,
-		TotalPrimitives: renderState.totalPrimitives,
-	}
-
 	// swapbuffers waits until the next vsync (if swapinterval is 1).
 	// This means that the render-thread will be blocked while waiting and no other gl-commands can be executed.
 	// To circumvent this, we wait until the frame is nearly over before issuing the call
 	delay := n.vSyncDelay - time.Since(n.fps.lastFrame)
 	delay = time.Duration(float32(delay) * 0.5)
-	time.Sleep(roundMillis(delay))
+	time.Sleep(roundMillis(delay)) // other engines can render in the meantime
 
+	engineLock.Lock()
+	n.makeCurrent()
 	n.window.SwapBuffers()
 	assert.NoGLError("Render frame %d", frame)
+	engineLock.Unlock()
 	n.renderStats.Store(renderStats)
 
 	glfw.PollEvents() // after buffer-swapping, as recommended by glfw
@@ -257,24 +398,87 @@ func (n *Engine) renderFrame(frameFunc DrawFrameFunc) bool {
 	return stop
 }
 
+// drawFrame executes the frame function and returns the statistics of the drawn frame.
+func (n *Engine) drawFrame(frameFunc DrawFrameFunc) (bool, RenderStats) {
+	frame, elapsed, framerate := n.fps.NextFrame()
+	n.handleResize()
+	c := n.clearColor
+	gl.ClearColor(c.R, c.G, c.B, c.A)
+
+	renderState := newRenderState(n.Camera, n.viewport, n.Shaders, &n.samplerManager)
+	n.updateGlobalUniforms(frame)
+	if n.viewport == (Viewport{0, 0, n.framebufferSize[0], n.framebufferSize[1]}) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	} else { // black bars around the viewport
+		gl.ClearColor(0, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		renderState.ClearViewport(n.clearColor)
+	}
+
+	var stop bool
+	if n.PostProcessing.Enabled() {
+		n.PostProcessing.render(renderState, n.Camera, func(sceneState *RenderState) {
+			stop = frameFunc(elapsed, sceneState)
+		})
+	} else {
+		stop = frameFunc(elapsed, renderState)
+	}
+	renderState.flush()
+	renderState.assertStacksEmpty("after rendering")
+
+	return stop, RenderStats{
+		Frame:           frame,
+		Framerate:       framerate,
+		TotalDrawCalls:  renderState.totalDrawCalls,
+		TotalPrimitives: renderState.totalPrimitives,
+		CulledMeshes:    renderState.culledMeshes,
+	}
+}
+
 func (n *Engine) Destroy() {
 	logrus.Debug("Waiting for current frame to finish")
 	n.window.SetShouldClose(true)
 	n.rendering.Lock() // Ensures that the render loop stopped and can't be started anymore
 
+	engineLock.Lock()
+	defer engineLock.Unlock()
+	n.makeCurrent()
+
 	assert.NoGLError("Engine shutting down")
 	logrus.Debug("Shutting down engine")
 
+	n.captureLock.Lock()
+	for _, c := range n.captureRequests {
+		close(c) // there won't be any frames anymore
+	}
+	n.captureRequests = nil
+	n.captureLock.Unlock()
+
 	n.InteractionSystem.RemoveAll()
-	n.Shaders.UnloadAll()
-	n.Textures.UnloadAll()
+	n.PostProcessing.Destroy()
+
+	for idx, e := range engines {
+		if e == n {
+			engines = append(engines[:idx], engines[idx+1:]...)
+			break
+		}
+	}
+	if len(engines) == 0 { // shared resources are not used anymore
+		n.Shaders.UnloadAll()
+		n.Textures.UnloadAll()
+		sharedLock.Lock()
+		sharedState = nil
+		sharedLock.Unlock()
+	}
 	assert.NoGLError("Engine shut down")
 
 	n.window.Destroy()
 	gl.CheckError() // for some reason, window.Destroy() succeeds, but triggers a gl error; ignore that error
 
 	engine = nil
-	engineLock.Unlock()
+	if len(engines) > 0 { // keep a valid context for loading/unloading shared resources
+		engines[0].makeCurrent()
+	}
 	logrus.Info("Engine shut down")
 }
 
@@ -287,25 +491,31 @@ func (n *Engine) maximizeCallback(_ *glfw.Window, _ bool) {
 }
 
 func (n *Engine) handleResize() {
-	// TODO: This function might try to change the window size to keep the aspect ratio.
-	// 		 If the window got maximized however, this is not possible and the call will do nothing.
-	//		 Create black-borders in this scenario.
-
 	if !n.windowResized {
 		return
 	}
 	n.windowResized = false
 	width, height := n.window.GetSize()
+	n.framebufferSize[0], n.framebufferSize[1] = n.window.GetFramebufferSize()
+	fullViewport := Viewport{0, 0, n.framebufferSize[0], n.framebufferSize[1]}
 
 	switch n.resizePolicy {
 	case ResizeAdjustViewport:
-		gl.Viewport(0, 0, width, height)
+		n.setViewport(fullViewport)
 
 	case ResizeKeepViewport:
 		// do nothing
 
+	case ResizeLetterbox:
+		n.setViewport(letterbox(fullViewport, n.desiredAspectRatio))
+
 	case ResizeKeepAspectRatio:
-		gl.Viewport(0, 0, width, height)
+		// Until the window size is adjusted, black bars are used.
+		// This is also the case if the window can't be resized (eg. if it is maximized).
+		n.setViewport(letterbox(fullViewport, n.desiredAspectRatio))
+		if n.window.GetAttrib(glfw.Maximized) != 0 {
+			break
+		}
 
 		if n.InteractionSystem.WindowSize()[0] == width { // the height was modified --> adjust width
 			newWidth := int(float32(height) * n.desiredAspectRatio)
@@ -324,14 +534,63 @@ func (n *Engine) handleResize() {
 		}
 	}
 
-	n.InteractionSystem.updateWindowSize(vmath.Vec2i{width, height})
+	windowSize := vmath.Vec2i{width, height}
+	n.InteractionSystem.updateWindowSize(windowSize, n.contentArea(windowSize))
 	logrus.Infof("Window size:    %v\n", n.InteractionSystem.WindowSize())
 }
 
+// contentArea returns the window area (in window coordinates) that is covered by the viewport.
+func (n *Engine) contentArea(windowSize vmath.Vec2i) vmath.Recti {
+	if n.framebufferSize[0] == 0 || n.framebufferSize[1] == 0 { // minimized
+		return vmath.Recti{Max: windowSize}
+	}
+	// the framebuffer size can differ from the window size (high-DPI monitors)
+	scaleX := float32(windowSize[0]) / float32(n.framebufferSize[0])
+	scaleY := float32(windowSize[1]) / float32(n.framebufferSize[1])
+
+	v := n.viewport
+	top := n.framebufferSize[1] - v.Y - v.Height // the viewport's origin is in the bottom-left corner
+	return vmath.Recti{
+		Min: vmath.Vec2i{int(float32(v.X) * scaleX), int(float32(top) * scaleY)},
+		Max: vmath.Vec2i{int(float32(v.X+v.Width) * scaleX), int(float32(top+v.Height) * scaleY)},
+	}
+}
+
+// letterbox returns the largest viewport with the given aspect ratio, centered within the available area.
+func letterbox(area Viewport, aspectRatio float32) Viewport {
+	if area.Height == 0 || aspectRatio <= 0 {
+		return area
+	}
+	v := area
+	if area.AspectRatio() > aspectRatio { // too wide --> bars left and right (pillarbox)
+		v.Width = int(float32(area.Height)*aspectRatio + 0.5)
+		v.X += (area.Width - v.Width) / 2
+	} else { // too high --> bars at the top and bottom (letterbox)
+		v.Height = int(float32(area.Width)/aspectRatio + 0.5)
+		v.Y += (area.Height - v.Height) / 2
+	}
+	return v
+}
+
+func (n *Engine) setViewport(viewport Viewport) {
+	n.viewport = viewport
+	gl.Viewport(viewport.X, viewport.Y, viewport.Width, viewport.Height)
+}
+
+// Viewport returns the viewport of the window's framebuffer.
+func (n *Engine) Viewport() Viewport {
+	return n.viewport
+}
+
 func roundMillis(d time.Duration) time.Duration {
 	return d / time.Millisecond * time.Millisecond
 }
 
+// Offscreen returns true if the engine renders without a visible window.
+func (n *Engine) Offscreen() bool {
+	return n.offscreen
+}
+
 // Window returns the underlying glfw window.
 // Should usually not be required/accessed by the user.
 func (n *Engine) Window() *glfw.Window {
@@ -361,8 +620,14 @@ func (n *Engine) RenderThread() *render.RenderThread {
 }
 
 // SetClearColor changes the clear color (background color)
+// The color is applied at the beginning of the next frame.
 func (n *Engine) SetClearColor(color color.Color) {
-	gl.ClearColor(color.R, color.G, color.B, color.A)
+	n.clearColor = color
+}
+
+// ClearColor returns the current clear color (background color)
+func (n *Engine) ClearColor() color.Color {
+	return n.clearColor
 }
 
 type RenderStats struct {
@@ -370,6 +635,7 @@ type RenderStats struct {
 	Framerate       float32 // frames per second
 	TotalDrawCalls  int
 	TotalPrimitives int
+	CulledMeshes    int // meshes that were skipped because they were outside the camera's view
 }
 
 func (r *RenderStats) String() string {
@@ -377,9 +643,10 @@ func (r *RenderStats) String() string {
 		"Frame         %d\n"+
 		"Framerate     %.2f fps\n"+
 		"Draw calls    %d\n"+
-		"Primitives    %d",
+		"Primitives    %d\n"+
+		"Culled meshes %d",
 		r.Frame, r.Framerate,
-		r.TotalDrawCalls, r.TotalPrimitives)
+		r.TotalDrawCalls, r.TotalPrimitives, r.CulledMeshes)
 }
 
 // RenderStats returns statistics about the last rendered frame
@@ -1,18 +1,28 @@
 package nora
 
 import (
+	"encoding/binary"
+
 	"github.com/maja42/gl"
 	"github.com/maja42/nora/assert"
 )
 
+// MaxIndexedVertexCount is the maximum number of vertices that can be used for indexed drawing.
+// Geometry with more than 0xFFFF vertices uses 32bit indices, which require the OES_element_index_uint extension on WebGL 1.
+const MaxIndexedVertexCount = 0xFFFFFFFF
+
 // Geometry represents an object's geometric properties.
 // Allows reading/merging/manipulating of the underlying geometry.
 type Geometry struct {
 	vertexCount      int
 	vertices         []float32
-	indices          []uint16
+	rawVertices      []byte         // byte-level vertex data; used instead of vertices if vertexFormat is set
+	vertexFormat     []VertexAttrib // nil for float32 vertex data
+	indices          []uint32       // nil if the geometry uses 16bit indices or no indexed drawing
+	indices16        []uint16       // 16bit indices are kept as provided, until they need to be modified (see wideIndices)
 	primitiveType    PrimitiveType
 	vertexAttributes []string
+	components       []int // float components of each vertex attribute; optional (see SetAttributeComponents)
 	bufferLayout     BufferLayout
 }
 
@@ -24,6 +34,20 @@ func NewGeometry(vertexCount int, vertices []float32, indices []uint16, primitiv
 	return g
 }
 
+// NewGeometry32 is equivalent to NewGeometry, but uses 32bit indices.
+func NewGeometry32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) *Geometry {
+	g := &Geometry{}
+	g.Set32(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout)
+	return g
+}
+
+// NewRawGeometry is equivalent to NewGeometry, but uses byte-level vertex data.
+func NewRawGeometry(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) *Geometry {
+	g := &Geometry{}
+	g.SetRaw(vertexCount, vertices, indices, primitiveType, vertexFormat, bufferLayout)
+	return g
+}
+
 // Empty returns true if the geometry does not contain any data.
 func (g *Geometry) Empty() bool {
 	return g.vertexCount == 0
@@ -37,15 +61,45 @@ func (g *Geometry) VertexCount() int {
 // Set replaces the existing geometry with new data.
 func (g *Geometry) Set(vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
 	AssertValidGeometry("", vertexCount, vertices, indices, primitiveType, vertexAttributes)
-	g.vertexCount = vertexCount
-	g.vertices = vertices
-	g.indices = indices
-	g.primitiveType = primitiveType
-	g.vertexAttributes = vertexAttributes
-	g.bufferLayout = bufferLayout
+	*g = Geometry{
+		vertexCount:      vertexCount,
+		vertices:         vertices,
+		indices16:        indices,
+		primitiveType:    primitiveType,
+		vertexAttributes: vertexAttributes,
+		bufferLayout:     bufferLayout,
+	}
 }
 
-// Checks if there's still enough space to append the given number of vertices.
+// Set32 is equivalent to Set, but uses 32bit indices.
+func (g *Geometry) Set32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	AssertValidGeometry32("", vertexCount, vertices, indices, primitiveType, vertexAttributes)
+	*g = Geometry{
+		vertexCount:      vertexCount,
+		vertices:         vertices,
+		indices:          indices,
+		primitiveType:    primitiveType,
+		vertexAttributes: vertexAttributes,
+		bufferLayout:     bufferLayout,
+	}
+}
+
+// SetRaw is equivalent to Set, but uses byte-level vertex data.
+// The vertex format defines the (ordered) set of attributes within the vertices and how they are stored.
+func (g *Geometry) SetRaw(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) {
+	AssertValidRawGeometry("", vertexCount, vertices, indices, primitiveType, vertexFormat)
+	*g = Geometry{
+		vertexCount:      vertexCount,
+		rawVertices:      vertices,
+		vertexFormat:     vertexFormat,
+		indices:          indices,
+		primitiveType:    primitiveType,
+		vertexAttributes: vertexAttributeNames(vertexFormat),
+		bufferLayout:     bufferLayout,
+	}
+}
+
+// CanAppendVertexCount checks if there's still enough space to append the given number of vertices.
 func (g *Geometry) CanAppendVertexCount(vertexCount int) bool {
 	var degenerateTris = 0
 	if g.vertexCount > 0 && g.primitiveType == gl.TRIANGLE_STRIP {
@@ -54,36 +108,57 @@ func (g *Geometry) CanAppendVertexCount(vertexCount int) bool {
 			degenerateTris++ // another degenerate for correct winding order needed
 		}
 	}
-	return g.vertexCount+degenerateTris+vertexCount <= 0xFFFF
+	return uint64(g.vertexCount+degenerateTris+vertexCount) <= MaxIndexedVertexCount
 }
 
 // Append merges new geometry at the end of the current one.
 func (g *Geometry) Append(vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) *Geometry {
+	return g.AppendGeometry(NewGeometry(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout))
+}
+
+// Append32 is equivalent to Append, but uses 32bit indices.
+func (g *Geometry) Append32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) *Geometry {
+	return g.AppendGeometry(NewGeometry32(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout))
+}
+
+// AppendRaw is equivalent to Append, but uses byte-level vertex data.
+func (g *Geometry) AppendRaw(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) *Geometry {
+	return g.AppendGeometry(NewRawGeometry(vertexCount, vertices, indices, primitiveType, vertexFormat, bufferLayout))
+}
+
+// AppendGeometry merges new geometry at the end of the current one.
+func (g *Geometry) AppendGeometry(other *Geometry) *Geometry {
 	if g.vertexCount == 0 {
-		g.Set(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout)
+		*g = *other
 		return g
 	}
-	AssertValidGeometry("", vertexCount, vertices, indices, primitiveType, vertexAttributes)
+	vertexCount, indexed, primitiveType := other.vertexCount, other.hasIndices(), other.primitiveType
 
 	assert.True(g.primitiveType == primitiveType, "Incompatible primitive type %s<=>%s", g.primitiveType, primitiveType)
-	assert.True(equalStringSlice(g.vertexAttributes, vertexAttributes), "Incompatible vertex attributes: %v <> %v", g.vertexAttributes, vertexAttributes)
-	assert.True(g.CanAppendVertexCount(vertexCount), "Resulting geometry is not indexable by uint16")
-	assert.True((g.indices != nil) == (indices != nil), "Incompatible indexed-drawing property")
+	assert.True(equalStringSlice(g.vertexAttributes, other.vertexAttributes), "Incompatible vertex attributes: %v <> %v", g.vertexAttributes, other.vertexAttributes)
+	assert.True(equalVertexFormat(g.vertexFormat, other.vertexFormat), "Incompatible vertex format: %v <> %v", g.vertexFormat, other.vertexFormat)
+	assert.True(g.CanAppendVertexCount(vertexCount), "Resulting geometry is not indexable by uint32")
+	assert.True(g.hasIndices() == indexed, "Incompatible indexed-drawing property")
+	if g.components == nil {
+		g.components = other.components
+	} else if other.components != nil {
+		assert.True(equalIntSlice(g.components, other.components), "Incompatible vertex attribute components: %v <> %v", g.components, other.components)
+	}
 
 	// Support could be added for some of the following cases:
 
-	if len(vertexAttributes) > 1 { // check buffer layout for compatibility
-		assert.True(g.bufferLayout == bufferLayout, "Incompatible buffer layouts")  // solvable by splicing the vertex array
-		assert.True(bufferLayout == InterleavedBuffer, "Unsupported buffer layout") // solvable by splicing the vertex array
+	if len(other.vertexAttributes) > 1 { // check buffer layout for compatibility
+		assert.True(g.bufferLayout == other.bufferLayout, "Incompatible buffer layouts")  // solvable by splicing the vertex array
+		assert.True(other.bufferLayout == InterleavedBuffer, "Unsupported buffer layout") // solvable by splicing the vertex array
 	}
 	assert.True(primitiveType == gl.POINTS || primitiveType == gl.LINES || primitiveType == gl.TRIANGLES || primitiveType == gl.TRIANGLE_STRIP, "Unsupported primitive type") // solvable by adding degenerate triangles
 
 	if primitiveType == gl.TRIANGLE_STRIP {
 		// create degenerate triangles by duplicating the two vertices at the merge point
-		if indices == nil { // duplicate vertices
-			vertexSize := len(vertices) / vertexCount
+		if !indexed && g.vertexFormat == nil { // duplicate vertices
+			vertexSize := len(other.vertices) / vertexCount
 			lastVertex := g.vertices[len(g.vertices)-vertexSize:]
-			firstVertex := vertices[:vertexSize]
+			firstVertex := other.vertices[:vertexSize]
 
 			g.vertices = append(g.vertices, lastVertex...)
 			g.vertices = append(g.vertices, firstVertex...)
@@ -91,10 +166,20 @@ func (g *Geometry) Append(vertexCount int, vertices []float32, indices []uint16,
 				// add another vertex to keep the winding order consistent
 				g.vertices = append(g.vertices, firstVertex...)
 			}
+		} else if !indexed { // duplicate byte-level vertices
+			vertexSize := VertexSize(g.vertexFormat)
+			lastVertex := g.rawVertices[len(g.rawVertices)-vertexSize:]
+			firstVertex := other.rawVertices[:vertexSize]
+
+			g.rawVertices = append(g.rawVertices, lastVertex...)
+			g.rawVertices = append(g.rawVertices, firstVertex...)
+			if g.vertexCount%2 != 0 {
+				g.rawVertices = append(g.rawVertices, firstVertex...)
+			}
 		} else { // duplicate indices
-			lastIdx := uint16(0) //g.indices[len(g.indices)-1]
-			firstIdx := indices[0] + uint16(g.vertexCount)
-			g.indices = append(g.indices, lastIdx, firstIdx)
+			lastIdx := uint32(0) //g.indices[len(g.indices)-1]
+			firstIdx := uint32(other.Index(0) + g.vertexCount)
+			g.indices = append(g.wideIndices(), lastIdx, firstIdx)
 			if g.vertexCount%2 != 0 {
 				// add another vertex to keep the winding order consistent
 				g.indices = append(g.indices, firstIdx)
@@ -103,21 +188,32 @@ func (g *Geometry) Append(vertexCount int, vertices []float32, indices []uint16,
 		g.vertexCount += 2 + g.vertexCount%2
 	}
 
-	firstIdx := len(g.indices)
-	g.vertices = append(g.vertices, vertices...)
-	g.indices = append(g.indices, indices...)
-	// offset indices:
-	for i := firstIdx; i < len(g.indices); i++ {
-		g.indices[i] += uint16(g.vertexCount)
+	g.vertices = append(g.vertices, other.vertices...)
+	g.rawVertices = append(g.rawVertices, other.rawVertices...)
+	if indexed {
+		indices := g.wideIndices()
+		for i, count := 0, other.IndexCount(); i < count; i++ {
+			indices = append(indices, uint32(other.Index(i)+g.vertexCount))
+		}
+		g.indices = indices
 	}
 	g.vertexCount += vertexCount
 	return g
 }
 
-// AppendGeometry merges new geometry at the end of the current one.
-func (g *Geometry) AppendGeometry(other *Geometry) *Geometry {
-	g.Append(other.vertexCount, other.vertices, other.indices, other.primitiveType, other.vertexAttributes, other.bufferLayout)
-	return g
+// hasIndices returns true if the geometry uses indexed drawing.
+func (g *Geometry) hasIndices() bool {
+	return g.indices != nil || g.indices16 != nil
+}
+
+// wideIndices converts 16bit indices into 32bit indices, so that they can be modified or extended.
+// Returns the 32bit indices; nil if the geometry does not use indexed drawing.
+func (g *Geometry) wideIndices() []uint32 {
+	if g.indices16 != nil {
+		g.indices = widenIndices(g.indices16)
+		g.indices16 = nil
+	}
+	return g.indices
 }
 
 func equalStringSlice(a, b []string) bool {
@@ -132,11 +228,133 @@ func equalStringSlice(a, b []string) bool {
 	return true
 }
 
+func equalIntSlice(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// widenIndices converts 16bit indices into 32bit indices.
+func widenIndices(indices []uint16) []uint32 {
+	if indices == nil {
+		return nil
+	}
+	wide := make([]uint32, len(indices))
+	for i, idx := range indices {
+		wide[i] = uint32(idx)
+	}
+	return wide
+}
+
+// narrowIndices converts 32bit indices into 16bit indices.
+// The caller needs to ensure that all indices are <= 0xFFFF.
+func narrowIndices(indices []uint32) []uint16 {
+	if indices == nil {
+		return nil
+	}
+	narrow := make([]uint16, len(indices))
+	for i, idx := range indices {
+		narrow[i] = uint16(idx)
+	}
+	return narrow
+}
+
+// indexBytes encodes 32bit indices for uploading them into an index buffer.
+func indexBytes(indices []uint32) []byte {
+	data := make([]byte, len(indices)*4)
+	for i, idx := range indices {
+		binary.LittleEndian.PutUint32(data[i*4:], idx)
+	}
+	return data
+}
+
 // AssertValidGeometry checks if the provided geometry is in-itself valid.
 // sProgKey is optional. If provided, the geometry is validated against the currently loaded shader program with that key.
 func AssertValidGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string) {
+	index := func(i int) int { return int(indices[i]) }
+	assertValidGeometry(sProgKey, vertexCount, vertices, len(indices), index, primitiveType, vertexAttributes)
+}
+
+// AssertValidGeometry32 is equivalent to AssertValidGeometry, but uses 32bit indices.
+func AssertValidGeometry32(sProgKey ShaderProgKey, vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string) {
+	index := func(i int) int { return int(indices[i]) }
+	assertValidGeometry(sProgKey, vertexCount, vertices, len(indices), index, primitiveType, vertexAttributes)
+}
+
+// AssertValidRawGeometry is equivalent to AssertValidGeometry, but uses byte-level vertex data.
+func AssertValidRawGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib) {
+	assertValidRawGeometry(sProgKey, vertexCount, vertices, indices, primitiveType, vertexFormat)
+}
+
+// assertValidRawGeometry checks byte-level geometry.
+func assertValidRawGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib) {
+	vertexSize := assertValidVertexFormat(vertexFormat)
+	if vertexCount > 0 {
+		assert.True(len(vertexFormat) > 0, "There are no vertex attributes")
+		assert.True(len(vertices) == vertexCount*vertexSize, "VertexCount and vertex data does not match (%d bytes per vertex)", vertexSize)
+	} else {
+		assert.True(len(vertices) == 0, "VertexCount and vertex data does not match. Should there be vertices?")
+	}
+	index := func(i int) int { return int(indices[i]) }
+	assertValidIndices(vertexCount, len(indices), index, primitiveType)
+
+	// Validate against shader program
+
+	if sProgKey == "" { // shader unknown --> skip
+		return
+	}
+
+	sProg, _ := resolveShader(sProgKey)
+	if !assert.True(sProg != nil, "Shader %q not loaded", sProgKey) {
+		return
+	}
+
+	assertShaderSupportsFormat(sProgKey, sProg, vertexFormat)
+	if vertexCount > 0 && len(sProg.attributeTypes) > len(vertexFormat) {
+		assert.Fail("Shader %q has %d vertex attributes. Geometry only contains %d", sProgKey, len(sProg.attributeTypes), len(vertexFormat))
+	}
+}
+
+// assertValidVertexFormat checks if the attributes of byte-level vertex data are valid.
+// Returns the vertex size in bytes.
+func assertValidVertexFormat(vertexFormat []VertexAttrib) int {
+	vertexSize := 0
+	for _, attr := range vertexFormat {
+		size, ok := componentSizes[attr.Type]
+		assert.True(ok, "Vertex attribute %s has unsupported component type", attr)
+		assert.True(attr.Components >= 1 && attr.Components <= 4, "Vertex attribute %s has invalid number of components (1-4)", attr)
+		assert.True(!attr.Normalized || (attr.Type != gl.FLOAT && attr.Type != HalfFloat), "Vertex attribute %s: only integer types can be normalized", attr)
+		// WebGL requires offsets to be a multiple of the component size
+		assert.True(!ok || vertexSize%size == 0, "Vertex attribute %s is not aligned (offset %d); add padding", attr, vertexSize)
+		vertexSize += attr.Size()
+	}
+	return vertexSize
+}
+
+// assertShaderSupportsFormat checks if the shader program can read the given vertex attributes.
+func assertShaderSupportsFormat(sProgKey ShaderProgKey, sProg *shaderProgram, vertexFormat []VertexAttrib) {
+	for _, attr := range vertexFormat {
+		typ, ok := sProg.attributeTypes[attr.Name]
+		if !assert.True(ok, "Shader %q does not support vertex attribute %s", sProgKey, attr.Name) {
+			continue
+		}
+		typProps, ok := vaTypePropertyMapping[typ]
+		if !assert.True(ok, "Shader %q has unsupported type 0x%x for vertex attribute %s", sProgKey, typ, attr.Name) {
+			continue
+		}
+		assert.True(attr.Components <= int(typProps.components), "Shader %q has %d components for vertex attribute %s", sProgKey, typProps.components, attr)
+	}
+}
+
+// assertValidGeometry checks float32 geometry.
+func assertValidGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []float32, indexCount int, index func(int) int, primitiveType PrimitiveType, vertexAttributes []string) {
 	// vertex data must be divisible by vertex count
-	indexCount := len(indices)
 	vertexSize := 0
 	if vertexCount > 0 {
 		vertexSize = len(vertices) / vertexCount
@@ -150,16 +368,51 @@ func AssertValidGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []flo
 		// vertex size must be big enough for all the attributes
 		assert.True(vertexSize >= len(vertexAttributes), "Vertex size is too small to fit all vertex attributes") // zero-size attributes don't exist
 	}
+	assertValidIndices(vertexCount, indexCount, index, primitiveType)
+
+	// Validate against shader program
+
+	if sProgKey == "" { // shader unknown --> skip
+		return
+	}
+
+	sProg, _ := resolveShader(sProgKey)
+	if !assert.True(sProg != nil, "Shader %q not loaded", sProgKey) {
+		return
+	}
+
+	// Check existence of attributes
+	expectedVertexSize := 0
+	for _, attr := range vertexAttributes {
+		typ, ok := sProg.attributeTypes[attr]
+		expectedVertexSize += int(vaTypePropertyMapping[typ].components)
+		if assert.True(ok, "Shader %q does not support vertex attribute %s", sProgKey, attr) {
+			_, ok = vaTypePropertyMapping[typ]
+			assert.True(ok, "Shader %q has unsupported type 0x%x for vertex attribute %s", sProgKey, typ, attr)
+		}
+	}
+	// Check missing attributes
+	if vertexCount > 0 && len(sProg.attributeTypes) > len(vertexAttributes) {
+		assert.Fail("Shader %q has %d vertex attributes. Geometry only contains %d", sProgKey, len(sProg.attributeTypes), len(vertexAttributes))
+	}
+
+	if vertexCount > 0 {
+		// Check size of vertex attributes
+		assert.True(vertexSize == expectedVertexSize, "Shader %q has %d elements per vertex. Geometry has %d elements.", sProgKey, expectedVertexSize, vertexSize)
+	}
+}
 
+// assertValidIndices checks if the indices (or vertices, if there are no indices) form valid primitives.
+func assertValidIndices(vertexCount int, indexCount int, index func(int) int, primitiveType PrimitiveType) {
 	if indexCount > 0 {
 		// vertices must be indexable
-		assert.True(vertexCount <= 0xFFFF, "Too many vertices to be indexed by uint16")
+		assert.True(uint64(vertexCount) <= MaxIndexedVertexCount, "Too many vertices to be indexed by uint32")
 		// indices must not reference out-of-bounds vertices
 		minIdx, maxIdx := vertexCount, -1
 		assert.Func(func() bool {
 			okay := true
-			for _, i := range indices {
-				idx := int(i)
+			for i := 0; i < indexCount; i++ {
+				idx := index(i)
 				if idx > maxIdx {
 					maxIdx = idx
 				}
@@ -202,32 +455,4 @@ func AssertValidGeometry(sProgKey ShaderProgKey, vertexCount int, vertices []flo
 	default:
 		assert.Fail("Unknown primitive type %q", primitiveType)
 	}
-
-	// Validate against shader program
-
-	if sProgKey == "" { // shader unknown --> skip
-		return
-	}
-
-	sProg, _ := engine.Shaders.resolve(sProgKey)
-	if !assert.True(sProg != nil, "Shader %q not loaded", sProgKey) {
-		return
-	}
-
-	// Check existence of attributes
-	expectedVertexSize := 0
-	for _, attr := range vertexAttributes {
-		typ, ok := sProg.attributeTypes[attr]
-		expectedVertexSize += int(vaTypePropertyMapping[typ].components)
-		assert.True(ok, "Shader %q does not support vertex attribute %s", sProgKey, attr)
-	}
-	// Check missing attributes
-	if vertexCount > 0 && len(sProg.attributeTypes) > len(vertexAttributes) {
-		assert.Fail("Shader %q has %d vertex attributes. Geometry only contains %d", sProgKey, len(sProg.attributeTypes), len(vertexAttributes))
-	}
-
-	if vertexCount > 0 {
-		// Check size of vertex attributes
-		assert.True(vertexSize == expectedVertexSize, "Shader %q has %d elements per vertex. Geometry has %d elements.", sProgKey, expectedVertexSize, vertexSize)
-	}
 }
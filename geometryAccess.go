@@ -0,0 +1,161 @@
+package nora
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/vmath"
+)
+
+// Vertices returns the float32 vertex data; nil for byte-level vertex data.
+// The caller must not modify the returned data.
+func (g *Geometry) Vertices() []float32 {
+	return g.vertices
+}
+
+// RawVertices returns the byte-level vertex data; nil for float32 vertex data.
+// The caller must not modify the returned data.
+func (g *Geometry) RawVertices() []byte {
+	return g.rawVertices
+}
+
+// Indices returns the vertex indices; nil if the geometry does not use indexed drawing.
+// 16bit indices are converted (once). The caller must not modify the returned data.
+func (g *Geometry) Indices() []uint32 {
+	return g.wideIndices()
+}
+
+// PrimitiveType returns the type of primitives that is drawn.
+func (g *Geometry) PrimitiveType() PrimitiveType {
+	return g.primitiveType
+}
+
+// VertexAttributes returns the (ordered) names of all vertex attributes.
+func (g *Geometry) VertexAttributes() []string {
+	return g.vertexAttributes
+}
+
+// VertexFormat returns the storage of all vertex attributes; nil for float32 vertex data.
+func (g *Geometry) VertexFormat() []VertexAttrib {
+	return g.vertexFormat
+}
+
+// BufferLayout returns how vertices are laid out within the vertex array.
+func (g *Geometry) BufferLayout() BufferLayout {
+	return g.bufferLayout
+}
+
+// IndexCount returns the number of vertices that are drawn (including vertices referenced multiple times).
+func (g *Geometry) IndexCount() int {
+	switch {
+	case g.indices16 != nil:
+		return len(g.indices16)
+	case g.indices != nil:
+		return len(g.indices)
+	}
+	return g.vertexCount
+}
+
+// Index returns the vertex that is drawn at the given position.
+// Used for iterating over all primitives, independent of indexed drawing.
+func (g *Geometry) Index(i int) int {
+	switch {
+	case g.indices16 != nil:
+		return int(g.indices16[i])
+	case g.indices != nil:
+		return int(g.indices[i])
+	}
+	return i
+}
+
+// Position returns the "position" attribute of a vertex.
+// Missing components are 0.
+func (g *Geometry) Position(i int) vmath.Vec3f {
+	var pos vmath.Vec3f
+	copy(pos[:], g.Attribute("position", i))
+	return pos
+}
+
+// Attribute returns the values of a vertex attribute.
+// Byte-level vertex data is converted to float32 (normalized if defined by the vertex format).
+// Multiple float32 vertex attributes require known attribute components (see SetAttributeComponents).
+func (g *Geometry) Attribute(name string, i int) []float32 {
+	if !assert.True(i >= 0 && i < g.vertexCount, "Vertex index %d out of range", i) {
+		return nil
+	}
+	if g.vertexFormat != nil {
+		return g.rawAttribute(name, i)
+	}
+
+	offset, components, ok := g.floatAttribute(name)
+	if !ok {
+		return nil
+	}
+	if g.bufferLayout == CompactBuffer {
+		offset += i * components
+	} else {
+		offset += i * len(g.vertices) / g.vertexCount
+	}
+	values := make([]float32, components)
+	copy(values, g.vertices[offset:])
+	return values
+}
+
+func (g *Geometry) rawAttribute(name string, i int) []float32 {
+	offset := 0 // in bytes
+	for _, attr := range g.vertexFormat {
+		if attr.Name != name {
+			offset += attr.Size()
+			continue
+		}
+		if g.bufferLayout == CompactBuffer {
+			offset = offset*g.vertexCount + i*attr.Size()
+		} else {
+			offset += i * VertexSize(g.vertexFormat)
+		}
+
+		compSize := componentSizes[attr.Type]
+		values := make([]float32, attr.Components)
+		for c := range values {
+			values[c] = decodeComponent(g.rawVertices[offset+c*compSize:], attr.Type, attr.Normalized)
+		}
+		return values
+	}
+	assert.Fail("Vertex attribute %q does not exist", name)
+	return nil
+}
+
+// decodeComponent converts a single little-endian vertex attribute component into a float.
+func decodeComponent(data []byte, compType gl.Enum, normalized bool) float32 {
+	var value, max float32
+	switch compType {
+	case gl.BYTE:
+		value, max = float32(int8(data[0])), math.MaxInt8
+	case gl.UNSIGNED_BYTE:
+		value, max = float32(data[0]), math.MaxUint8
+	case gl.SHORT:
+		value, max = float32(int16(binary.LittleEndian.Uint16(data))), math.MaxInt16
+	case gl.UNSIGNED_SHORT:
+		value, max = float32(binary.LittleEndian.Uint16(data)), math.MaxUint16
+	case gl.INT:
+		value, max = float32(int32(binary.LittleEndian.Uint32(data))), math.MaxInt32
+	case gl.UNSIGNED_INT:
+		value, max = float32(binary.LittleEndian.Uint32(data)), math.MaxUint32
+	case HalfFloat:
+		return float16frombits(binary.LittleEndian.Uint16(data))
+	case gl.FLOAT:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	default:
+		assert.Fail("Unsupported vertex attribute type 0x%x", compType)
+		return 0
+	}
+	if !normalized {
+		return value
+	}
+	if value /= max; value < -1 { // signed types map [-max-1, max] to [-1, 1]
+		return -1
+	}
+	return value
+}
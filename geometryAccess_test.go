@@ -0,0 +1,120 @@
+package nora
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/maja42/gl"
+)
+
+func TestDecodeComponent(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       func(d *VertexData)
+		compType   gl.Enum
+		normalized bool
+		want       float32
+	}{
+		{"float", func(d *VertexData) { d.Float32(-1.5) }, gl.FLOAT, false, -1.5},
+		{"normalized float is unchanged", func(d *VertexData) { d.Float32(3) }, gl.FLOAT, true, 3},
+		{"half float", func(d *VertexData) { d.Float16(0.25) }, HalfFloat, false, 0.25},
+		{"byte", func(d *VertexData) { d.Int8(-7) }, gl.BYTE, false, -7},
+		{"normalized byte", func(d *VertexData) { d.Int8(127) }, gl.BYTE, true, 1},
+		{"normalized byte minimum is clamped", func(d *VertexData) { d.Int8(-128) }, gl.BYTE, true, -1},
+		{"unsigned byte", func(d *VertexData) { d.Uint8(200) }, gl.UNSIGNED_BYTE, false, 200},
+		{"normalized unsigned byte", func(d *VertexData) { d.Uint8(255) }, gl.UNSIGNED_BYTE, true, 1},
+		{"normalized unsigned byte zero", func(d *VertexData) { d.Uint8(0) }, gl.UNSIGNED_BYTE, true, 0},
+		{"short", func(d *VertexData) { d.Int16(-300) }, gl.SHORT, false, -300},
+		{"normalized short", func(d *VertexData) { d.Int16(-32767) }, gl.SHORT, true, -1},
+		{"unsigned short", func(d *VertexData) { d.Uint16(65535) }, gl.UNSIGNED_SHORT, false, 65535},
+		{"normalized unsigned short", func(d *VertexData) { d.Uint16(65535) }, gl.UNSIGNED_SHORT, true, 1},
+		{"int", func(d *VertexData) { d.Int32(-100000) }, gl.INT, false, -100000},
+		{"unsigned int", func(d *VertexData) { d.Uint32(100000) }, gl.UNSIGNED_INT, false, 100000},
+		{"normalized unsigned int", func(d *VertexData) { d.Uint32(math.MaxUint32) }, gl.UNSIGNED_INT, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data VertexData
+			tt.data(&data)
+			if got := decodeComponent(data, tt.compType, tt.normalized); got != tt.want {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryAttribute(t *testing.T) {
+	format := []VertexAttrib{
+		{Name: "position", Components: 2, Type: gl.FLOAT},
+		{Name: "color", Components: 4, Type: gl.UNSIGNED_BYTE, Normalized: true},
+		{Name: "texCoord", Components: 2, Type: gl.UNSIGNED_SHORT, Normalized: true},
+	}
+	var interleaved VertexData
+	interleaved.Float32(1, 2).Uint8(255, 0, 0, 255).Uint16(0, 65535)
+	interleaved.Float32(3, 4).Uint8(0, 255, 0, 0).Uint16(65535, 0)
+	var compact VertexData
+	compact.Float32(1, 2, 3, 4)
+	compact.Uint8(255, 0, 0, 255, 0, 255, 0, 0)
+	compact.Uint16(0, 65535, 65535, 0)
+
+	floatGeometry := func(layout BufferLayout) *Geometry {
+		vertices := []float32{1, 2, 5, 3, 4, 6} // interleaved position (2) and value (1)
+		if layout == CompactBuffer {
+			vertices = []float32{1, 2, 3, 4, 5, 6}
+		}
+		g := NewGeometry(2, vertices, nil, gl.POINTS, []string{"position", "value"}, layout)
+		g.SetAttributeComponents(2, 1)
+		return g
+	}
+
+	tests := []struct {
+		name   string
+		geom   *Geometry
+		attr   string
+		vertex int
+		want   []float32
+	}{
+		{"float interleaved", floatGeometry(InterleavedBuffer), "position", 1, []float32{3, 4}},
+		{"float interleaved second attribute", floatGeometry(InterleavedBuffer), "value", 0, []float32{5}},
+		{"float compact", floatGeometry(CompactBuffer), "position", 1, []float32{3, 4}},
+		{"float compact second attribute", floatGeometry(CompactBuffer), "value", 1, []float32{6}},
+		{"single float attribute", NewGeometry(2, []float32{1, 2, 3, 4, 5, 6}, nil, gl.POINTS, []string{"position"}, InterleavedBuffer), "position", 1, []float32{4, 5, 6}},
+		{"raw interleaved", NewRawGeometry(2, interleaved, nil, gl.POINTS, format, InterleavedBuffer), "position", 1, []float32{3, 4}},
+		{"raw interleaved normalized", NewRawGeometry(2, interleaved, nil, gl.POINTS, format, InterleavedBuffer), "color", 0, []float32{1, 0, 0, 1}},
+		{"raw interleaved last attribute", NewRawGeometry(2, interleaved, nil, gl.POINTS, format, InterleavedBuffer), "texCoord", 1, []float32{1, 0}},
+		{"raw compact", NewRawGeometry(2, compact, nil, gl.POINTS, format, CompactBuffer), "position", 0, []float32{1, 2}},
+		{"raw compact normalized", NewRawGeometry(2, compact, nil, gl.POINTS, format, CompactBuffer), "color", 1, []float32{0, 1, 0, 0}},
+		{"raw compact last attribute", NewRawGeometry(2, compact, nil, gl.POINTS, format, CompactBuffer), "texCoord", 0, []float32{0, 1}},
+		{"out of range", floatGeometry(InterleavedBuffer), "position", 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.geom.Attribute(tt.attr, tt.vertex); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryIndices16(t *testing.T) {
+	vertices := []float32{0, 0, 1, 0, 0, 1, 1, 1}
+	indices := []uint16{0, 1, 2, 2, 1, 3}
+	g := NewGeometry(4, vertices, indices, gl.TRIANGLES, []string{"position"}, InterleavedBuffer)
+
+	if g.indices16 == nil || g.indices != nil {
+		t.Fatalf("16bit indices were converted")
+	}
+	if g.IndexCount() != 6 || g.Index(5) != 3 {
+		t.Errorf("got %d indices, last one is %d", g.IndexCount(), g.Index(5))
+	}
+
+	g.Append(4, vertices, indices, gl.TRIANGLES, []string{"position"}, InterleavedBuffer)
+	want := []uint32{0, 1, 2, 2, 1, 3, 4, 5, 6, 6, 5, 7}
+	if !reflect.DeepEqual(g.Indices(), want) {
+		t.Errorf("got indices %v after appending, want %v", g.Indices(), want)
+	}
+	if !reflect.DeepEqual(indices, []uint16{0, 1, 2, 2, 1, 3}) {
+		t.Errorf("appending modified the original indices: %v", indices)
+	}
+}
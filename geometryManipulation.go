@@ -0,0 +1,319 @@
+package nora
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+)
+
+// SetAttributeComponents defines the number of float components of each vertex attribute (eg. 2 for "position" in 2D).
+// Required for manipulating geometry with multiple float32 vertex attributes,
+// because the components are otherwise only known by the shader.
+func (g *Geometry) SetAttributeComponents(components ...int) {
+	assert.True(len(components) == len(g.vertexAttributes), "Expected components for %d vertex attributes, got %d", len(g.vertexAttributes), len(components))
+	total := 0
+	for _, c := range components {
+		total += c
+	}
+	assert.True(total*g.vertexCount == len(g.vertices), "Vertex components don't match vertex data size")
+	g.components = components
+}
+
+// attributeComponents returns the number of float components of each vertex attribute.
+func (g *Geometry) attributeComponents() ([]int, bool) {
+	if g.components != nil {
+		return g.components, true
+	}
+	if len(g.vertexAttributes) == 1 && g.vertexCount > 0 {
+		return []int{len(g.vertices) / g.vertexCount}, true
+	}
+	return nil, false
+}
+
+// floatAttribute locates a float32 vertex attribute.
+// Returns the offset of the attribute (interleaved: within the vertex, compact: of the first vertex) and the number of components.
+func (g *Geometry) floatAttribute(name string) (offset, components int, ok bool) {
+	if !assert.True(g.vertexFormat == nil, "Not supported for byte-level vertex data") {
+		return 0, 0, false
+	}
+	comps, ok := g.attributeComponents()
+	if !assert.True(ok, "Unknown vertex attribute components (see SetAttributeComponents)") {
+		return 0, 0, false
+	}
+	for i, attr := range g.vertexAttributes {
+		if attr == name {
+			if g.bufferLayout == CompactBuffer {
+				offset *= g.vertexCount
+			}
+			return offset, comps[i], true
+		}
+		offset += comps[i]
+	}
+	assert.Fail("Vertex attribute %q does not exist", name)
+	return 0, 0, false
+}
+
+// hasAttribute returns true if the geometry contains the given vertex attribute.
+func (g *Geometry) hasAttribute(name string) bool {
+	for _, attr := range g.vertexAttributes {
+		if attr == name {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachAttribute calls fn with the attribute values of every vertex.
+// The values can be modified in-place.
+func (g *Geometry) forEachAttribute(name string, fn func(values []float32)) {
+	offset, components, ok := g.floatAttribute(name)
+	if !ok || g.vertexCount == 0 {
+		return
+	}
+	stride := len(g.vertices) / g.vertexCount
+	if g.bufferLayout == CompactBuffer {
+		stride = components
+	}
+	for v := 0; v < g.vertexCount; v++ {
+		start := offset + v*stride
+		fn(g.vertices[start : start+components])
+	}
+}
+
+// Copy returns a deep copy of the geometry.
+// Needed before manipulating geometry that shares its data with others.
+func (g *Geometry) Copy() *Geometry {
+	c := *g
+	c.vertices = append([]float32(nil), g.vertices...)
+	c.rawVertices = append([]byte(nil), g.rawVertices...)
+	if g.indices != nil {
+		c.indices = append([]uint32(nil), g.indices...)
+	}
+	if g.indices16 != nil {
+		c.indices16 = append([]uint16(nil), g.indices16...)
+	}
+	return &c
+}
+
+// Transform applies the transformation to the "position" attribute of all vertices.
+// The vertex data is modified in-place.
+func (g *Geometry) Transform(trans vmath.Mat4f) {
+	g.TransformAttribute("position", trans)
+}
+
+// TransformAttribute applies the transformation to an attribute with up to 4 components.
+// Missing components are 0, except for the 4th one, which is 1.
+// The vertex data is modified in-place.
+func (g *Geometry) TransformAttribute(name string, trans vmath.Mat4f) {
+	g.forEachAttribute(name, func(values []float32) {
+		if !assert.True(len(values) <= 4, "Vertex attribute %q has too many components for a transformation", name) {
+			return
+		}
+		vec := vmath.Vec4f{0, 0, 0, 1}
+		copy(vec[:], values)
+		vec = trans.MulVec(vec)
+		copy(values, vec[:])
+	})
+}
+
+// Recolor sets the color attribute of all vertices.
+// Color attributes with 3 components ignore the alpha channel.
+func (g *Geometry) Recolor(name string, c color.Color) {
+	rgba := [4]float32{c.R, c.G, c.B, c.A}
+	g.forEachAttribute(name, func(values []float32) {
+		copy(values, rgba[:])
+	})
+}
+
+// RemapTexCoords maps the texture coordinates of all vertices from [0, 1] into the given rectangle.
+// Used for placing textures within texture atlases.
+func (g *Geometry) RemapTexCoords(name string, rect vmath.Rectf) {
+	size := rect.Size()
+	g.forEachAttribute(name, func(values []float32) {
+		if !assert.True(len(values) == 2, "Texture coordinates %q must have 2 components", name) {
+			return
+		}
+		values[0] = rect.Min[0] + values[0]*size[0]
+		values[1] = rect.Min[1] + values[1]*size[1]
+	})
+}
+
+// Bounds returns the 2D bounding rectangle of the "position" attribute.
+func (g *Geometry) Bounds() vmath.Rectf {
+	min, max := g.Bounds3D()
+	return vmath.Rectf{
+		Min: vmath.Vec2f{min[0], min[1]},
+		Max: vmath.Vec2f{max[0], max[1]},
+	}
+}
+
+// Bounds3D returns the axis-aligned bounding box of the "position" attribute.
+// Missing components are 0.
+func (g *Geometry) Bounds3D() (vmath.Vec3f, vmath.Vec3f) {
+	if g.vertexCount == 0 {
+		return vmath.Vec3f{}, vmath.Vec3f{}
+	}
+	inf := float32(math.Inf(1))
+	min := vmath.Vec3f{inf, inf, inf}
+	max := vmath.Vec3f{-inf, -inf, -inf}
+	extend := func(values []float32) {
+		for i := 0; i < 3; i++ {
+			var v float32
+			if i < len(values) {
+				v = values[i]
+			}
+			if v < min[i] {
+				min[i] = v
+			}
+			if v > max[i] {
+				max[i] = v
+			}
+		}
+	}
+	if !g.hasAttribute("position") {
+		return vmath.Vec3f{}, vmath.Vec3f{}
+	}
+	if g.vertexFormat != nil {
+		for v := 0; v < g.vertexCount; v++ {
+			extend(g.rawAttribute("position", v))
+		}
+	} else {
+		g.forEachAttribute("position", extend)
+	}
+	if min[0] > max[0] { // unknown attribute components
+		return vmath.Vec3f{}, vmath.Vec3f{}
+	}
+	return min, max
+}
+
+// SetBufferLayout converts the vertex data into the given buffer layout.
+func (g *Geometry) SetBufferLayout(layout BufferLayout) {
+	if g.bufferLayout == layout {
+		return
+	}
+	if len(g.vertexAttributes) <= 1 || g.vertexCount == 0 {
+		g.bufferLayout = layout
+		return
+	}
+	if !assert.True(g.vertexFormat == nil, "Not supported for byte-level vertex data") {
+		return
+	}
+	comps, ok := g.attributeComponents()
+	if !assert.True(ok, "Unknown vertex attribute components (see SetAttributeComponents)") {
+		return
+	}
+
+	vertexSize := len(g.vertices) / g.vertexCount
+	vertices := make([]float32, len(g.vertices))
+	offset := 0 // of the attribute within a vertex
+	for _, c := range comps {
+		for v := 0; v < g.vertexCount; v++ {
+			interleaved := v*vertexSize + offset
+			compact := offset*g.vertexCount + v*c
+			if layout == InterleavedBuffer {
+				copy(vertices[interleaved:interleaved+c], g.vertices[compact:compact+c])
+			} else {
+				copy(vertices[compact:compact+c], g.vertices[interleaved:interleaved+c])
+			}
+		}
+		offset += c
+	}
+	g.vertices = vertices
+	g.bufferLayout = layout
+}
+
+// ToTriangles converts triangle strips and fans into indexed triangles, which can be merged with other triangle geometry.
+// Degenerate triangles referencing the same vertex index multiple times are removed.
+// Weld can be used beforehand for detecting degenerate triangles of non-indexed geometry.
+func (g *Geometry) ToTriangles() {
+	if g.primitiveType != gl.TRIANGLE_STRIP && g.primitiveType != gl.TRIANGLE_FAN {
+		return
+	}
+	index := func(i int) uint32 { return uint32(g.Index(i)) }
+	count := g.IndexCount()
+
+	var indices []uint32
+	if count > 2 {
+		indices = make([]uint32, 0, 3*(count-2))
+	}
+	for i := 0; i+2 < count; i++ {
+		var a, b, c uint32
+		if g.primitiveType == gl.TRIANGLE_FAN {
+			a, b, c = index(0), index(i+1), index(i+2)
+		} else if i%2 == 0 {
+			a, b, c = index(i), index(i+1), index(i+2)
+		} else { // odd triangles of strips have reversed winding order
+			a, b, c = index(i+1), index(i), index(i+2)
+		}
+		if a == b || b == c || a == c {
+			continue
+		}
+		indices = append(indices, a, b, c)
+	}
+	g.indices, g.indices16 = indices, nil
+	g.primitiveType = gl.TRIANGLES
+}
+
+// Weld merges vertices with identical attributes and uses indexed drawing to reference them.
+// Reduces the size of geometry generated from individual primitives.
+func (g *Geometry) Weld() {
+	if g.vertexCount == 0 {
+		return
+	}
+	layout := g.bufferLayout
+	g.SetBufferLayout(InterleavedBuffer)
+	if g.bufferLayout != InterleavedBuffer {
+		return
+	}
+
+	var vertex func(i int) string
+	if g.vertexFormat != nil {
+		vertexSize := len(g.rawVertices) / g.vertexCount
+		vertex = func(i int) string { return string(g.rawVertices[i*vertexSize : (i+1)*vertexSize]) }
+	} else {
+		floats := len(g.vertices) / g.vertexCount
+		var data VertexData
+		vertex = func(i int) string {
+			data = data[:0]
+			data.Float32(g.vertices[i*floats : (i+1)*floats]...)
+			return string(data)
+		}
+	}
+
+	unique := make(map[string]uint32, g.vertexCount)
+	remap := make([]uint32, g.vertexCount)
+	var vertices []byte
+	for i := 0; i < g.vertexCount; i++ {
+		key := vertex(i)
+		idx, ok := unique[key]
+		if !ok {
+			idx = uint32(len(unique))
+			unique[key] = idx
+			vertices = append(vertices, key...)
+		}
+		remap[i] = idx
+	}
+
+	if !g.hasIndices() {
+		g.indices = remap
+	} else {
+		for i, idx := range g.wideIndices() {
+			g.indices[i] = remap[idx]
+		}
+	}
+	g.vertexCount = len(unique)
+
+	if g.vertexFormat != nil {
+		g.rawVertices = vertices
+	} else {
+		g.vertices = make([]float32, len(vertices)/4)
+		for i := range g.vertices {
+			g.vertices[i] = math.Float32frombits(binary.LittleEndian.Uint32(vertices[i*4:]))
+		}
+	}
+	g.SetBufferLayout(layout)
+}
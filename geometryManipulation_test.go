@@ -0,0 +1,258 @@
+package nora
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/vmath"
+)
+
+func TestGeometrySetBufferLayout(t *testing.T) {
+	// 3 vertices with a 2-component position and a 1-component "value"
+	interleaved := []float32{
+		0, 1, 10,
+		2, 3, 11,
+		4, 5, 12,
+	}
+	compact := []float32{
+		0, 1, 2, 3, 4, 5,
+		10, 11, 12,
+	}
+
+	tests := []struct {
+		name     string
+		vertices []float32
+		from, to BufferLayout
+		want     []float32
+	}{
+		{"interleaved to compact", interleaved, InterleavedBuffer, CompactBuffer, compact},
+		{"compact to interleaved", compact, CompactBuffer, InterleavedBuffer, interleaved},
+		{"unchanged", interleaved, InterleavedBuffer, InterleavedBuffer, interleaved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGeometry(3, append([]float32(nil), tt.vertices...), nil, gl.POINTS, []string{"position", "value"}, tt.from)
+			g.SetAttributeComponents(2, 1)
+			g.SetBufferLayout(tt.to)
+
+			if g.BufferLayout() != tt.to {
+				t.Errorf("buffer layout is %s, want %s", g.BufferLayout(), tt.to)
+			}
+			if !reflect.DeepEqual(g.Vertices(), tt.want) {
+				t.Errorf("got vertices %v, want %v", g.Vertices(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryToTriangles(t *testing.T) {
+	tests := []struct {
+		name          string
+		vertexCount   int
+		indices       []uint32
+		primitiveType PrimitiveType
+		want          []uint32
+	}{
+		{"strip", 5, nil, gl.TRIANGLE_STRIP, []uint32{0, 1, 2, 2, 1, 3, 2, 3, 4}},
+		{"indexed strip", 4, []uint32{3, 2, 1, 0}, gl.TRIANGLE_STRIP, []uint32{3, 2, 1, 1, 2, 0}},
+		{"fan", 5, nil, gl.TRIANGLE_FAN, []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}},
+		{"degenerate strip triangles are removed", 5, []uint32{0, 1, 2, 2, 3, 4}, gl.TRIANGLE_STRIP, []uint32{0, 1, 2, 3, 2, 4}},
+		{"too few vertices", 2, nil, gl.TRIANGLE_STRIP, nil},
+		{"triangles are unchanged", 3, nil, gl.TRIANGLES, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGeometry32(tt.vertexCount, make([]float32, tt.vertexCount), tt.indices, tt.primitiveType, []string{"position"}, InterleavedBuffer)
+			g.ToTriangles()
+
+			if g.PrimitiveType() != gl.TRIANGLES {
+				t.Errorf("primitive type is %s, want TRIANGLES", g.PrimitiveType())
+			}
+			if !reflect.DeepEqual(g.Indices(), tt.want) {
+				t.Errorf("got indices %v, want %v", g.Indices(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryWeld(t *testing.T) {
+	tests := []struct {
+		name         string
+		vertexCount  int
+		vertices     []float32
+		indices      []uint32
+		layout       BufferLayout
+		wantVertices []float32
+		wantIndices  []uint32
+	}{
+		{
+			name:         "non-indexed",
+			vertexCount:  6,
+			vertices:     []float32{0, 0, 1, 0, 0, 1, 0, 1, 1, 0, 1, 1},
+			layout:       InterleavedBuffer,
+			wantVertices: []float32{0, 0, 1, 0, 0, 1, 1, 1},
+			wantIndices:  []uint32{0, 1, 2, 2, 1, 3},
+		},
+		{
+			name:         "indexed",
+			vertexCount:  4,
+			vertices:     []float32{0, 0, 1, 0, 0, 0, 1, 1},
+			indices:      []uint32{0, 1, 3, 2, 3, 1},
+			layout:       InterleavedBuffer,
+			wantVertices: []float32{0, 0, 1, 0, 1, 1},
+			wantIndices:  []uint32{0, 1, 2, 0, 2, 1},
+		},
+		{
+			name:         "unique vertices",
+			vertexCount:  3,
+			vertices:     []float32{0, 0, 1, 0, 0, 1},
+			layout:       InterleavedBuffer,
+			wantVertices: []float32{0, 0, 1, 0, 0, 1},
+			wantIndices:  []uint32{0, 1, 2},
+		},
+		{
+			name:         "compact layout is kept",
+			vertexCount:  3,
+			vertices:     []float32{0, 1, 0, 5, 6, 5}, // position: 0, 1, 0 / value: 5, 6, 5
+			layout:       CompactBuffer,
+			wantVertices: []float32{0, 1, 5, 6},
+			wantIndices:  []uint32{0, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGeometry32(tt.vertexCount, tt.vertices, tt.indices, gl.TRIANGLES, []string{"position", "value"}, tt.layout)
+			g.SetAttributeComponents(1, 1)
+			g.Weld()
+
+			if g.VertexCount() != len(tt.wantVertices)/2 {
+				t.Errorf("vertex count is %d, want %d", g.VertexCount(), len(tt.wantVertices)/2)
+			}
+			if g.BufferLayout() != tt.layout {
+				t.Errorf("buffer layout is %s, want %s", g.BufferLayout(), tt.layout)
+			}
+			if !reflect.DeepEqual(g.Vertices(), tt.wantVertices) {
+				t.Errorf("got vertices %v, want %v", g.Vertices(), tt.wantVertices)
+			}
+			if !reflect.DeepEqual(g.Indices(), tt.wantIndices) {
+				t.Errorf("got indices %v, want %v", g.Indices(), tt.wantIndices)
+			}
+		})
+	}
+}
+
+func TestGeometryWeldRaw(t *testing.T) {
+	format := []VertexAttrib{
+		{Name: "position", Components: 2, Type: gl.FLOAT},
+		{Name: "color", Components: 4, Type: gl.UNSIGNED_BYTE, Normalized: true},
+	}
+	var data VertexData
+	data.Float32(0, 0).Uint8(255, 0, 0, 255)
+	data.Float32(1, 0).Uint8(255, 0, 0, 255)
+	data.Float32(0, 0).Uint8(255, 0, 0, 255)
+	data.Float32(0, 0).Uint8(0, 255, 0, 255) // same position, different color
+
+	g := NewRawGeometry(4, data, nil, gl.POINTS, format, InterleavedBuffer)
+	g.Weld()
+
+	if g.VertexCount() != 3 {
+		t.Errorf("vertex count is %d, want 3", g.VertexCount())
+	}
+	if want := []uint32{0, 1, 0, 2}; !reflect.DeepEqual(g.Indices(), want) {
+		t.Errorf("got indices %v, want %v", g.Indices(), want)
+	}
+	if want := []byte(data[:2*12]); !reflect.DeepEqual(g.RawVertices()[:2*12], want) {
+		t.Errorf("got vertices %v, want %v", g.RawVertices()[:2*12], want)
+	}
+}
+
+func TestGeometryBounds3D(t *testing.T) {
+	tests := []struct {
+		name       string
+		vertices   []float32
+		attributes []string
+		components []int
+		layout     BufferLayout
+		min, max   vmath.Vec3f
+	}{
+		{
+			name:       "2D",
+			vertices:   []float32{-1, 2, 3, -4, 0, 0},
+			attributes: []string{"position"},
+			components: []int{2},
+			min:        vmath.Vec3f{-1, -4, 0},
+			max:        vmath.Vec3f{3, 2, 0},
+		},
+		{
+			name:       "3D",
+			vertices:   []float32{1, 2, 3, -1, -2, -3, 0, 5, 0},
+			attributes: []string{"position"},
+			components: []int{3},
+			min:        vmath.Vec3f{-1, -2, -3},
+			max:        vmath.Vec3f{1, 5, 3},
+		},
+		{
+			name:       "interleaved with other attributes",
+			vertices:   []float32{9, 1, 1, 1, 9, 2, 2, 2},
+			attributes: []string{"value", "position"},
+			components: []int{1, 3},
+			min:        vmath.Vec3f{1, 1, 1},
+			max:        vmath.Vec3f{2, 2, 2},
+		},
+		{
+			name:       "compact with other attributes",
+			vertices:   []float32{1, 1, 2, 2, 9, 9},
+			attributes: []string{"position", "value"},
+			components: []int{2, 1},
+			layout:     CompactBuffer,
+			min:        vmath.Vec3f{1, 1, 0},
+			max:        vmath.Vec3f{2, 2, 0},
+		},
+		{
+			name:       "no position",
+			vertices:   []float32{1, 2, 3, 4},
+			attributes: []string{"texCoord"},
+			components: []int{2},
+		},
+		{
+			name:       "empty",
+			attributes: []string{"position"},
+			components: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := 0
+			for _, c := range tt.components {
+				size += c
+			}
+			g := NewGeometry(len(tt.vertices)/size, tt.vertices, nil, gl.POINTS, tt.attributes, tt.layout)
+			g.SetAttributeComponents(tt.components...)
+
+			min, max := g.Bounds3D()
+			if min != tt.min || max != tt.max {
+				t.Errorf("got bounds %v - %v, want %v - %v", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestGeometryBounds3DRaw(t *testing.T) {
+	format := []VertexAttrib{
+		{Name: "color", Components: 4, Type: gl.UNSIGNED_BYTE, Normalized: true},
+		{Name: "position", Components: 2, Type: gl.SHORT},
+	}
+	var data VertexData
+	data.Uint8(0, 0, 0, 0).Int16(-5, 3)
+	data.Uint8(0, 0, 0, 0).Int16(7, -2)
+	g := NewRawGeometry(2, data, nil, gl.POINTS, format, InterleavedBuffer)
+
+	min, max := g.Bounds3D()
+	if want := (vmath.Vec3f{-5, -2, 0}); min != want {
+		t.Errorf("got min %v, want %v", min, want)
+	}
+	if want := (vmath.Vec3f{7, 3, 0}); max != want {
+		t.Errorf("got max %v, want %v", max, want)
+	}
+}
@@ -39,6 +39,7 @@ type InteractionSystem struct {
 
 	// state:
 	windowSize          vmath.Vec2i
+	contentArea         vmath.Recti // window area covered by the viewport
 	cursorPos           vmath.Vec2i // window coordinates
 	pressedMouseButtons map[glfw.MouseButton]struct{}
 	pressedKeys         map[glfw.Key]struct{}
@@ -53,6 +54,7 @@ func newInteractionSystem(windowSize vmath.Vec2i, cursorPos vmath.Vec2i) Interac
 		keyEventFuncs:         make(map[CallbackID]OnKeyEventFunc),
 
 		windowSize:          windowSize,
+		contentArea:         vmath.Recti{Max: windowSize},
 		cursorPos:           cursorPos,
 		pressedMouseButtons: make(map[glfw.MouseButton]struct{}, 3),
 		pressedKeys:         make(map[glfw.Key]struct{}, 5),
@@ -179,6 +181,7 @@ func (i *InteractionSystem) RemoveAll() {
 	i.keyEventFuncs = make(map[CallbackID]OnKeyEventFunc)
 	i.mouseMoveEventFuncs = make(map[CallbackID]OnMouseMoveEventFunc)
 	i.mouseButtonEventFuncs = make(map[CallbackID]OnMouseButtonEventFunc)
+	i.scrollEventFuncs = make(map[CallbackID]OnScrollEventFunc)
 }
 
 // WindowSize returns the size of the opened window from the last frame.
@@ -186,40 +189,55 @@ func (i *InteractionSystem) WindowSize() vmath.Vec2i {
 	return i.windowSize
 }
 
+// ContentArea returns the window area that is covered by the viewport, in window coordinates.
+// Can be smaller than the window (eg. due to black bars if the aspect ratio is kept).
+func (i *InteractionSystem) ContentArea() vmath.Recti {
+	return i.contentArea
+}
+
 // WindowSpaceToClipSpace converts 2D window space [0, windowSize] into 2D clip space [-1,+1] coordinates.
+// Clip space is relative to the content area. Positions outside of it are outside of [-1,+1].
 func (i *InteractionSystem) WindowSpaceToClipSpace(windowSpace vmath.Vec2f) vmath.Vec2f {
+	pos, size := i.contentArea.Min.Vec2f(), i.contentAreaSize()
 	return vmath.Vec2f{
-		2*windowSpace[0]/float32(i.windowSize[0]) - 1,
-		-2*windowSpace[1]/float32(i.windowSize[1]) + 1,
+		2*(windowSpace[0]-pos[0])/size[0] - 1,
+		-2*(windowSpace[1]-pos[1])/size[1] + 1,
 	}
 }
 
 // ClipSpaceToWindowSpace converts 2D clip space [-1,+1] into 2D window space [0, windowSize] coordinates.
 func (i *InteractionSystem) ClipSpaceToWindowSpace(clipSpace vmath.Vec2f) vmath.Vec2f {
+	pos, size := i.contentArea.Min.Vec2f(), i.contentAreaSize()
 	return vmath.Vec2f{
-		(clipSpace[0] + 1) * float32(i.windowSize[0]) / 2,
-		(clipSpace[0] - 1) * float32(i.windowSize[0]) / -2,
+		(clipSpace[0]+1)*size[0]/2 + pos[0],
+		(clipSpace[1]-1)*size[1]/-2 + pos[1],
 	}
 }
 
 // WindowSpaceDistToClipSpaceDist converts a 2D window space distance into a clip space distance.
 // The calculation is independent of the clip space's origin (center of screen).
 func (i *InteractionSystem) WindowSpaceDistToClipSpaceDist(windowSpaceDist vmath.Vec2f) vmath.Vec2f {
+	size := i.contentAreaSize()
 	return vmath.Vec2f{
-		windowSpaceDist[0] * 2 / float32(i.windowSize[0]),
-		windowSpaceDist[1] * -2 / float32(i.windowSize[1]),
+		windowSpaceDist[0] * 2 / size[0],
+		windowSpaceDist[1] * -2 / size[1],
 	}
 }
 
 // ClipSpaceDistToWindowSpaceDist converts a 2D clip space distance into a window space distance.
 // The calculation is independent of the clip space's origin (center of screen).
 func (i *InteractionSystem) ClipSpaceDistToWindowSpaceDist(clipSpaceDist vmath.Vec2f) vmath.Vec2f {
+	size := i.contentAreaSize()
 	return vmath.Vec2f{
-		clipSpaceDist[0] / 2 * float32(i.windowSize[0]),
-		clipSpaceDist[1] / -2 * float32(i.windowSize[1]),
+		clipSpaceDist[0] / 2 * size[0],
+		clipSpaceDist[1] / -2 * size[1],
 	}
 }
 
+func (i *InteractionSystem) contentAreaSize() vmath.Vec2f {
+	return i.contentArea.Max.Sub(i.contentArea.Min).Vec2f()
+}
+
 // MousePosWindowSpace returns the current cursor position in window coordinates.
 // (0,0) = top left corner
 func (i *InteractionSystem) MousePosWindowSpace() vmath.Vec2i {
@@ -232,6 +250,14 @@ func (i *InteractionSystem) MousePosClipSpace() vmath.Vec2f {
 	return i.WindowSpaceToClipSpace(i.cursorPos.Vec2f())
 }
 
+// IsCursorInContent returns true if the cursor is located within the content area.
+// Returns false if the cursor is located on black bars or outside of the window.
+func (i *InteractionSystem) IsCursorInContent() bool {
+	c := i.cursorPos
+	return c[0] >= i.contentArea.Min[0] && c[0] < i.contentArea.Max[0] &&
+		c[1] >= i.contentArea.Min[1] && c[1] < i.contentArea.Max[1]
+}
+
 // Returns true if the given mouse button is currently pressed.
 // Call with glfw.MouseButtonLeft, glfw.MouseButtonRight, glfw.MouseButtonMiddle, ...
 func (i *InteractionSystem) IsMouseButtonPressed(button glfw.MouseButton) bool {
@@ -245,8 +271,9 @@ func (i *InteractionSystem) IsKeyPressed(key glfw.Key) bool {
 	return ok
 }
 
-func (i *InteractionSystem) updateWindowSize(size vmath.Vec2i) {
+func (i *InteractionSystem) updateWindowSize(size vmath.Vec2i, contentArea vmath.Recti) {
 	i.windowSize = size
+	i.contentArea = contentArea
 }
 
 // Fires all queued input events and clears the input buffer
@@ -9,12 +9,15 @@ import (
 // Material defines how geometry is rendered.
 type Material struct {
 	sProgKey ShaderProgKey
+	// transparent materials are drawn after opaque ones and sorted back-to-front by render queues
+	transparent bool
 
 	textures map[string]TextureKey
+	uniforms map[string]uniformValue
 
-	uniformf   map[string][]float32
-	uniformMat map[string][]float32
-	uniformi   map[string][]int32
+	// validation against the shader program:
+	validatedProg sProgID         // shader program the material was last validated against
+	invalid       map[string]bool // textures and uniforms that are not accepted by the validated shader
 }
 
 // NewMaterial creates a new material based on the given shader.
@@ -22,165 +25,167 @@ func NewMaterial(sProgKey ShaderProgKey) *Material {
 	return &Material{
 		sProgKey: sProgKey,
 		textures: make(map[string]TextureKey),
-		uniformf: make(map[string][]float32),
-		uniformi: make(map[string][]int32),
+		uniforms: make(map[string]uniformValue),
 	}
 }
 
 // SetShader changes the shader program.
 func (m *Material) SetShader(sProgKey ShaderProgKey) {
 	m.sProgKey = sProgKey
+	m.invalidate()
+}
+
+// Transparent returns true if the material is not fully opaque.
+func (m *Material) Transparent() bool {
+	return m.transparent
+}
+
+// SetTransparent marks the material as (partially) transparent.
+// Render queues draw transparent materials after all opaque ones, from back to front.
+func (m *Material) SetTransparent(transparent bool) {
+	m.transparent = transparent
 }
 
 func (m *Material) AddTextureBinding(uniformName string, texKey TextureKey) {
+	_, ok := m.textures[uniformName]
 	m.textures[uniformName] = texKey
+	if !ok { // validation only depends on the uniform, not on the texture
+		m.invalidate()
+	}
 }
 
 func (m *Material) Uniform1f(uniformName string, x float32) {
-	m.uniformf[uniformName] = []float32{x}
+	m.uniformFloats(uniformName, gl.FLOAT, []float32{x})
 }
 
 func (m *Material) Uniform1fv(uniformName string, v []float32) {
-	m.uniformf[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT, v)
 }
 
 func (m *Material) Uniform1i(uniformName string, x int32) {
-	m.uniformi[uniformName] = []int32{x}
+	m.uniformInts(uniformName, gl.INT, []int32{x})
 }
 
 func (m *Material) Uniform1iv(uniformName string, v []int32) {
-	m.uniformi[uniformName] = v
+	m.uniformInts(uniformName, gl.INT, v)
 }
 
 func (m *Material) Uniform2f(uniformName string, x, y float32) {
-	m.uniformf[uniformName] = []float32{x, y}
+	m.uniformFloats(uniformName, gl.FLOAT_VEC2, []float32{x, y})
 }
 
 func (m *Material) Uniform2fv(uniformName string, v []float32) {
-	m.uniformf[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_VEC2, v)
 }
 
 func (m *Material) Uniform2i(uniformName string, x, y int32) {
-	m.uniformi[uniformName] = []int32{x, y}
+	m.uniformInts(uniformName, gl.INT_VEC2, []int32{x, y})
 }
 
 func (m *Material) Uniform2iv(uniformName string, v []int32) {
-	m.uniformi[uniformName] = v
+	m.uniformInts(uniformName, gl.INT_VEC2, v)
 }
 
 func (m *Material) Uniform3f(uniformName string, x, y, z float32) {
-	m.uniformf[uniformName] = []float32{x, y, z}
+	m.uniformFloats(uniformName, gl.FLOAT_VEC3, []float32{x, y, z})
 }
 
 func (m *Material) Uniform3fv(uniformName string, v []float32) {
-	m.uniformf[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_VEC3, v)
 }
 
 func (m *Material) Uniform3i(uniformName string, x, y, z int32) {
-	m.uniformi[uniformName] = []int32{x, y, z}
+	m.uniformInts(uniformName, gl.INT_VEC3, []int32{x, y, z})
 }
 
 func (m *Material) Uniform3iv(uniformName string, v []int32) {
-	m.uniformi[uniformName] = v
+	m.uniformInts(uniformName, gl.INT_VEC3, v)
 }
 
 func (m *Material) Uniform4f(uniformName string, x, y, z, w float32) {
-	m.uniformf[uniformName] = []float32{x, y, z, w}
+	m.uniformFloats(uniformName, gl.FLOAT_VEC4, []float32{x, y, z, w})
 }
 
 func (m *Material) Uniform4fv(uniformName string, v []float32) {
-	m.uniformf[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_VEC4, v)
 }
 
 func (m *Material) Uniform4fColor(uniformName string, c color.Color) {
-	m.uniformf[uniformName] = []float32{c.R, c.G, c.B, c.A}
+	m.uniformFloats(uniformName, gl.FLOAT_VEC4, []float32{c.R, c.G, c.B, c.A})
 }
 
 func (m *Material) Uniform4i(uniformName string, x, y, z, w int32) {
-	m.uniformi[uniformName] = []int32{x, y, z, w}
+	m.uniformInts(uniformName, gl.INT_VEC4, []int32{x, y, z, w})
 }
 
 func (m *Material) Uniform4iv(uniformName string, v []int32) {
-	m.uniformi[uniformName] = v
+	m.uniformInts(uniformName, gl.INT_VEC4, v)
 }
 
 func (m *Material) UniformMatrix2fv(uniformName string, v []float32) {
-	m.uniformMat[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_MAT2, v)
 }
 
 func (m *Material) UniformMatrix3fv(uniformName string, v []float32) {
-	m.uniformMat[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_MAT3, v)
 }
 
 func (m *Material) UniformMatrix4fv(uniformName string, v []float32) {
-	m.uniformMat[uniformName] = v
+	m.uniformFloats(uniformName, gl.FLOAT_MAT4, v)
+}
+
+func (m *Material) uniformFloats(uniformName string, typ gl.Enum, v []float32) {
+	if u, ok := newFloatUniform(typ, v); ok {
+		m.setUniform(uniformName, u)
+	}
+}
+
+func (m *Material) uniformInts(uniformName string, typ gl.Enum, v []int32) {
+	if u, ok := newIntUniform(typ, v); ok {
+		m.setUniform(uniformName, u)
+	}
+}
+
+func (m *Material) setUniform(uniformName string, u uniformValue) {
+	old, ok := m.uniforms[uniformName]
+	m.uniforms[uniformName] = u
+	if !ok || old.typ != u.typ || old.elements() != u.elements() {
+		m.invalidate()
+	}
+}
+
+// invalidate causes the material to be validated again before it is applied the next time.
+func (m *Material) invalidate() {
+	m.validatedProg = sProgID{}
 }
 
 // apply must only be called during sync. rendering (expects locked context)
-func (m *Material) apply(shader *shaderProgram, texTargets *samplerManager) {
+func (m *Material) apply(shader *shaderProgram, id sProgID, texTargets *samplerManager) {
 	// The caller needs to pass the (correct) shader program based on the internal sProgKey
 
-	for name, texKey := range m.textures {
-		loc, ok := shader.getUniformLocation(name)
-		if !assert.True(ok, "Uniform %q is not supported by shader %q", name, m.sProgKey) {
-			continue // ignore uniform
+	if m.validatedProg != id {
+		// validate once per shader program (and after every hot-reload), instead of complaining every frame
+		var errs []error
+		errs, m.invalid = m.validate(shader)
+		m.validatedProg = id
+		if len(errs) > 0 {
+			assert.Fail("%s", &MaterialError{Shader: m.sProgKey, Errors: errs})
 		}
-		texTargets.bind(loc, texKey)
 	}
 
-	for name, u := range m.uniformf {
+	for name, texKey := range m.textures {
 		loc, ok := shader.getUniformLocation(name)
-		if !assert.True(ok, "Uniform %q is not supported by shader %q", name, m.sProgKey) {
+		if !ok || m.invalid[name] {
 			continue // ignore uniform
 		}
-
-		switch len(u) {
-		case 1:
-			gl.Uniform1fv(loc, u)
-		case 2:
-			gl.Uniform2fv(loc, u)
-		case 3:
-			gl.Uniform3fv(loc, u)
-		case 4:
-			gl.Uniform4fv(loc, u)
-		default:
-			assert.Fail("Unknown uniform type (floats of length %d)", len(u))
-		}
+		texTargets.bind(loc, texKey)
 	}
-	for name, u := range m.uniformMat {
-		loc, ok := shader.getUniformLocation(name)
-		if !assert.True(ok, "Uniform %q is not supported by shader %q", name, m.sProgKey) {
-			continue // ignore uniform
-		}
 
-		switch len(u) {
-		case 4:
-			gl.UniformMatrix2fv(loc, u)
-		case 9:
-			gl.UniformMatrix3fv(loc, u)
-		case 16:
-			gl.UniformMatrix4fv(loc, u)
-		default:
-			assert.Fail("Unknown uniform type (float matrix of length %d)", len(u))
-		}
-	}
-	for name, u := range m.uniformi {
+	for name, u := range m.uniforms {
 		loc, ok := shader.getUniformLocation(name)
-		if !assert.True(ok, "Uniform %q is not supported by shader %q", name, m.sProgKey) {
+		if !ok || m.invalid[name] {
 			continue // ignore uniform
 		}
-
-		switch len(u) {
-		case 1:
-			gl.Uniform1iv(loc, u)
-		case 2:
-			gl.Uniform2iv(loc, u)
-		case 3:
-			gl.Uniform3iv(loc, u)
-		case 4:
-			gl.Uniform4iv(loc, u)
-		default:
-			assert.Fail("Unknown uniform type (ints of length %d)", len(u))
-		}
+		u.upload(loc)
 	}
 }
@@ -0,0 +1,82 @@
+package nora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaterialError lists all problems of a material's textures and uniforms with its shader program.
+type MaterialError struct {
+	Shader ShaderProgKey
+	Errors []error
+}
+
+func (e *MaterialError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = "\n\t- " + err.Error()
+	}
+	return fmt.Sprintf("material is incompatible with shader %q:%s", e.Shader, strings.Join(lines, ""))
+}
+
+// Validate checks the material's textures and uniforms against the types reflected by the shader program.
+// Returns a *MaterialError listing all problems.
+// Materials are also validated automatically when they are used for drawing and after shader hot-reloads.
+func (m *Material) Validate() error {
+	sProg, _ := resolveShader(m.sProgKey)
+	if sProg == nil {
+		return fmt.Errorf("shader %q is not loaded", m.sProgKey)
+	}
+	if errs, _ := m.validate(sProg); len(errs) > 0 {
+		return &MaterialError{Shader: m.sProgKey, Errors: errs}
+	}
+	return nil
+}
+
+// validate returns all problems of the material with the given shader program,
+// together with the names of the offending textures and uniforms.
+func (m *Material) validate(shader *shaderProgram) ([]error, map[string]bool) {
+	var errs []error
+	invalid := make(map[string]bool)
+
+	textureNames := make([]string, 0, len(m.textures))
+	for name := range m.textures {
+		textureNames = append(textureNames, name)
+	}
+	sort.Strings(textureNames)
+	for _, name := range textureNames {
+		active, ok := shader.uniformTypes[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("texture %q: no such uniform", name))
+		case !isSamplerType(active.typ):
+			errs = append(errs, fmt.Errorf("texture %q: uniform is of type %s, not a sampler", name, uniformTypeName(active.typ)))
+		default:
+			continue
+		}
+		invalid[name] = true
+	}
+
+	uniformNames := make([]string, 0, len(m.uniforms))
+	for name := range m.uniforms {
+		uniformNames = append(uniformNames, name)
+	}
+	sort.Strings(uniformNames)
+	for _, name := range uniformNames {
+		u := m.uniforms[name]
+		active, ok := shader.uniformTypes[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("uniform %q: no such uniform", name))
+		case !uniformAccepts(active.typ, u.typ):
+			errs = append(errs, fmt.Errorf("uniform %q: shader expects %s, material provides %s", name, uniformTypeName(active.typ), uniformTypeName(u.typ)))
+		case u.elements() > active.size:
+			errs = append(errs, fmt.Errorf("uniform %q: material provides %d elements, shader array has %d", name, u.elements(), active.size))
+		default:
+			continue
+		}
+		invalid[name] = true
+	}
+	return errs, invalid
+}
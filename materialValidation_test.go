@@ -0,0 +1,153 @@
+package nora
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maja42/gl"
+)
+
+func TestUniformAccepts(t *testing.T) {
+	tests := []struct {
+		shaderType, valueType gl.Enum
+		want                  bool
+	}{
+		{gl.FLOAT, gl.FLOAT, true},
+		{gl.FLOAT, gl.INT, false},
+		{gl.FLOAT_VEC3, gl.FLOAT_VEC3, true},
+		{gl.FLOAT_VEC3, gl.FLOAT_VEC4, false},
+		{gl.INT_VEC2, gl.INT_VEC2, true},
+		{gl.INT_VEC2, gl.FLOAT_VEC2, false},
+		{gl.FLOAT_MAT4, gl.FLOAT_MAT4, true},
+		{gl.FLOAT_MAT4, gl.FLOAT_MAT3, false},
+		{gl.BOOL, gl.INT, true},
+		{gl.BOOL, gl.FLOAT, true},
+		{gl.BOOL, gl.INT_VEC2, false},
+		{gl.BOOL_VEC2, gl.INT_VEC2, true},
+		{gl.BOOL_VEC3, gl.FLOAT_VEC3, true},
+		{gl.BOOL_VEC4, gl.INT_VEC4, true},
+		{gl.BOOL_VEC4, gl.INT_VEC3, false},
+		{gl.SAMPLER_2D, gl.INT, true},
+		{gl.SAMPLER_CUBE, gl.INT, true},
+		{gl.SAMPLER_2D, gl.FLOAT, false},
+	}
+	for _, tt := range tests {
+		if got := uniformAccepts(tt.shaderType, tt.valueType); got != tt.want {
+			t.Errorf("uniformAccepts(%s, %s) = %v, want %v", uniformTypeName(tt.shaderType), uniformTypeName(tt.valueType), got, tt.want)
+		}
+	}
+}
+
+func TestMaterialValidate(t *testing.T) {
+	shader := &shaderProgram{
+		uniformTypes: map[string]activeUniform{
+			"color":   {gl.FLOAT_VEC4, 1},
+			"weights": {gl.FLOAT, 4},
+			"enabled": {gl.BOOL, 1},
+			"sampler": {gl.SAMPLER_2D, 1},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(m *Material)
+		errs    int
+		invalid []string
+	}{
+		{
+			name: "valid",
+			setup: func(m *Material) {
+				m.Uniform4f("color", 1, 0, 0, 1)
+				m.Uniform1fv("weights", []float32{1, 2, 3})
+				m.Uniform1i("enabled", 1)
+				m.AddTextureBinding("sampler", "tex")
+			},
+		},
+		{
+			name:    "unknown uniform",
+			setup:   func(m *Material) { m.Uniform1f("missing", 1) },
+			errs:    1,
+			invalid: []string{"missing"},
+		},
+		{
+			name:    "wrong type",
+			setup:   func(m *Material) { m.Uniform3f("color", 1, 0, 0) },
+			errs:    1,
+			invalid: []string{"color"},
+		},
+		{
+			name:    "too many array elements",
+			setup:   func(m *Material) { m.Uniform1fv("weights", []float32{1, 2, 3, 4, 5}) },
+			errs:    1,
+			invalid: []string{"weights"},
+		},
+		{
+			name:    "unknown texture",
+			setup:   func(m *Material) { m.AddTextureBinding("missing", "tex") },
+			errs:    1,
+			invalid: []string{"missing"},
+		},
+		{
+			name:    "texture bound to non-sampler",
+			setup:   func(m *Material) { m.AddTextureBinding("color", "tex") },
+			errs:    1,
+			invalid: []string{"color"},
+		},
+		{
+			name: "multiple problems",
+			setup: func(m *Material) {
+				m.Uniform4f("color", 1, 1, 1, 1)
+				m.Uniform1i("weights", 1)
+				m.Uniform2f("other", 1, 2)
+				m.AddTextureBinding("texture", "tex")
+			},
+			errs:    3,
+			invalid: []string{"other", "texture", "weights"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMaterial("shader")
+			tt.setup(m)
+
+			errs, invalid := m.validate(shader)
+			if len(errs) != tt.errs {
+				t.Errorf("got %d errors %v, want %d", len(errs), errs, tt.errs)
+			}
+			var names []string
+			for _, name := range []string{"color", "enabled", "missing", "other", "sampler", "texture", "weights"} {
+				if invalid[name] {
+					names = append(names, name)
+				}
+			}
+			if len(invalid) != len(names) || !reflect.DeepEqual(names, tt.invalid) {
+				t.Errorf("got invalid %v, want %v", invalid, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestMaterialInvalidate(t *testing.T) {
+	m := NewMaterial("shader")
+	validated := sProgID{id: 1}
+
+	tests := []struct {
+		name       string
+		change     func()
+		invalidate bool
+	}{
+		{"new uniform", func() { m.Uniform1f("a", 1) }, true},
+		{"new value", func() { m.Uniform1f("a", 2) }, false},
+		{"new type", func() { m.Uniform1i("a", 2) }, true},
+		{"new array size", func() { m.Uniform1iv("a", []int32{1, 2}) }, true},
+		{"new texture binding", func() { m.AddTextureBinding("tex", "a") }, true},
+		{"new texture", func() { m.AddTextureBinding("tex", "b") }, false},
+	}
+	for _, tt := range tests {
+		m.validatedProg = validated
+		tt.change()
+		if invalidated := m.validatedProg != validated; invalidated != tt.invalidate {
+			t.Errorf("%s: invalidated = %v, want %v", tt.name, invalidated, tt.invalidate)
+		}
+	}
+}
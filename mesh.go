@@ -1,7 +1,9 @@
 package nora
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/maja42/gl"
 	"github.com/maja42/nora/assert"
@@ -21,15 +23,31 @@ type Mesh struct {
 
 	primitiveType PrimitiveType
 	bufferLayout  BufferLayout
+	usage         BufferUsage
 	vboSize       int // in bytes
+	vboCapacity   int // allocated bytes; can be bigger than vboSize for stream buffers
+
+	// dynamic buffers collect sub-data changes in a local copy and upload them once before drawing
+	vboCopy              []byte
+	dirtyStart, dirtyEnd int // modified bytes within vboCopy
 
 	vertexAttributes []string
+	vertexFormat     []VertexAttrib // nil for float32 vertex data
+
+	layout        *vertexLayout // cached attribute pointers
+	layoutVersion int           // incremented every time the vertex layout changes; invalidates the cached layout
 
 	vertexCount int
 	vertexSize  int // in bytes
 
 	indexCount     int
+	indexType      gl.Enum // UNSIGNED_SHORT or UNSIGNED_INT
 	primitiveCount int
+
+	// culling:
+	bounds       BoundingBox // model space
+	hasBounds    bool        // false if the bounds are unknown; the mesh is never culled
+	customBounds bool        // bounds were defined by the user and are not recalculated
 }
 
 // NewMesh creates a new mesh with the given material
@@ -38,11 +56,13 @@ func NewMesh(mat *Material) *Mesh {
 	return &Mesh{
 		material: mat,
 		vbo:      gl.CreateBuffer(),
+		usage:    StaticBuffer,
 	}
 }
 
 // Destroy deletes all resources associated with the mesh
 func (m *Mesh) Destroy() {
+	m.layout = nil
 	gl.DeleteBuffers(m.vbo, m.ibo)
 }
 
@@ -56,6 +76,17 @@ func (m *Mesh) SetMaterial(mat *Material) {
 	m.material = mat
 }
 
+// Usage returns the usage hint of the mesh's vertex buffer.
+func (m *Mesh) Usage() BufferUsage {
+	return m.usage
+}
+
+// SetUsage defines how often the mesh's vertex data will be modified.
+// Takes effect the next time the vertex data is set.
+func (m *Mesh) SetUsage(usage BufferUsage) {
+	m.usage = usage
+}
+
 // SetVertexData is equivalent to SetGeometry and defines the mesh's geometry.
 //	- vertexCount       Number of vertices
 //	- vertices			Array of raw vertex data
@@ -63,46 +94,251 @@ func (m *Mesh) SetMaterial(mat *Material) {
 //	- primitiveType 	The type of primitives that is drawn.
 //  - vertexAttributes 	The (ordered) set of attributes within the vertices.
 //  - bufferLayout      How vertices are laid out within the vertex array.
+// The mesh's bounding box is calculated from the "position" attribute, unless it was defined with SetBounds.
 func (m *Mesh) SetVertexData(vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
-	assert.True(m.vbo.Value != 0, "Mesh was not initialized correctly")
+	m.setVertexData(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout)
+	m.calculateBounds(&Geometry{vertexCount: vertexCount, vertices: vertices, vertexAttributes: vertexAttributes, bufferLayout: bufferLayout})
+}
+
+func (m *Mesh) setVertexData(vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	index := func(i int) int { return int(indices[i]) }
+	assertValidGeometry(m.material.sProgKey, vertexCount, vertices, len(indices), index, primitiveType, vertexAttributes)
+	m.setVertexProperties(vertexCount, len(vertices)*4, len(indices), primitiveType, vertexAttributes, nil, bufferLayout)
 
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	if m.orphanVertexBuffer(len(vertices) * 4) {
+		gl.BufferSubDataFloat32(gl.ARRAY_BUFFER, 0, vertices)
+	} else {
+		gl.BufferDataFloat32(gl.ARRAY_BUFFER, vertices, gl.Enum(m.usage))
+	}
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.setVertexCopy(func(d *VertexData) { d.Float32(vertices...) })
+
+	m.setIndices16(indices)
+}
+
+// SetVertexData32 is equivalent to SetVertexData, but uses 32bit indices.
+// If there are few enough vertices, the indices are converted and uploaded as 16bit indices.
+// Otherwise, WebGL 1 requires the OES_element_index_uint extension.
+func (m *Mesh) SetVertexData32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	m.setVertexData32(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout)
+	m.calculateBounds(&Geometry{vertexCount: vertexCount, vertices: vertices, vertexAttributes: vertexAttributes, bufferLayout: bufferLayout})
+}
+
+func (m *Mesh) setVertexData32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	if vertexCount <= 0xFFFF {
+		m.setVertexData(vertexCount, vertices, narrowIndices(indices), primitiveType, vertexAttributes, bufferLayout)
+		return
+	}
+	index := func(i int) int { return int(indices[i]) }
+	assertValidGeometry(m.material.sProgKey, vertexCount, vertices, len(indices), index, primitiveType, vertexAttributes)
+	m.setVertexProperties(vertexCount, len(vertices)*4, len(indices), primitiveType, vertexAttributes, nil, bufferLayout)
+
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	if m.orphanVertexBuffer(len(vertices) * 4) {
+		gl.BufferSubDataFloat32(gl.ARRAY_BUFFER, 0, vertices)
+	} else {
+		gl.BufferDataFloat32(gl.ARRAY_BUFFER, vertices, gl.Enum(m.usage))
+	}
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.setVertexCopy(func(d *VertexData) { d.Float32(vertices...) })
+
+	m.setIndices32(indices)
+}
+
+// SetRawVertexData is equivalent to SetVertexData, but uses byte-level vertex data.
+// This allows integer vertex data and smaller data types, like normalized bytes for colors or half floats.
+//	- vertexCount       Number of vertices
+//	- vertices			Array of raw vertex data. VertexData can be used for creating it.
+//	- indices 			Optional array of indices. Uploaded as 16bit indices if possible.
+//	- primitiveType 	The type of primitives that is drawn.
+//	- vertexFormat 		The (ordered) set of attributes within the vertices and how they are stored.
+//	- bufferLayout      How vertices are laid out within the vertex array.
+func (m *Mesh) SetRawVertexData(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) {
+	m.setRawVertexData(vertexCount, vertices, indices, primitiveType, vertexFormat, bufferLayout)
+	m.calculateBounds(&Geometry{vertexCount: vertexCount, rawVertices: vertices, vertexFormat: vertexFormat, vertexAttributes: vertexAttributeNames(vertexFormat), bufferLayout: bufferLayout})
+}
+
+func (m *Mesh) setRawVertexData(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) {
+	assertValidRawGeometry(m.material.sProgKey, vertexCount, vertices, indices, primitiveType, vertexFormat)
+	m.setVertexProperties(vertexCount, len(vertices), len(indices), primitiveType, vertexAttributeNames(vertexFormat), vertexFormat, bufferLayout)
+
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	if m.orphanVertexBuffer(len(vertices)) {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, vertices)
+	} else {
+		gl.BufferData(gl.ARRAY_BUFFER, vertices, gl.Enum(m.usage))
+	}
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.setVertexCopy(func(d *VertexData) { d.Uint8(vertices...) })
+
+	if vertexCount <= 0xFFFF {
+		m.setIndices16(narrowIndices(indices))
+	} else {
+		m.setIndices32(indices)
+	}
+}
+
+// orphanVertexBuffer allocates new storage for the bound vertex buffer, if the mesh is a stream buffer.
+// The previous storage is released by the driver as soon as pending draw calls finished,
+// which avoids synchronization between the CPU and GPU.
+// Returns false if the buffer was not orphaned and the data needs to be uploaded with BufferData.
+func (m *Mesh) orphanVertexBuffer(size int) bool {
+	if m.usage != StreamBuffer || size == 0 {
+		m.vboCapacity = size
+		return false
+	}
+	if size > m.vboCapacity {
+		m.vboCapacity = size + size/2 // room for growing geometry
+	}
+	gl.BufferInit(gl.ARRAY_BUFFER, m.vboCapacity, gl.STREAM_DRAW)
+	return true
+}
+
+// setVertexCopy stores a local copy of the vertex data for dynamic buffers.
+func (m *Mesh) setVertexCopy(write func(d *VertexData)) {
+	m.dirtyStart, m.dirtyEnd = 0, 0
+	if m.usage != DynamicBuffer {
+		m.vboCopy = nil
+		return
+	}
+	data := VertexData(m.vboCopy[:0])
+	write(&data)
+	m.vboCopy = data
+}
+
+// uploadVertexChanges uploads all pending sub-data changes of dynamic buffers.
+func (m *Mesh) uploadVertexChanges() {
+	if m.dirtyStart >= m.dirtyEnd {
+		return
+	}
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, m.dirtyStart, m.vboCopy[m.dirtyStart:m.dirtyEnd])
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.dirtyStart, m.dirtyEnd = 0, 0
+}
+
+// markVertexChange extends the range of modified bytes of dynamic buffers.
+func (m *Mesh) markVertexChange(start, end int) {
+	if m.dirtyStart >= m.dirtyEnd {
+		m.dirtyStart, m.dirtyEnd = start, end
+		return
+	}
+	if start < m.dirtyStart {
+		m.dirtyStart = start
+	}
+	if end > m.dirtyEnd {
+		m.dirtyEnd = end
+	}
+}
+
+func (m *Mesh) setIndices16(indices []uint16) {
+	if len(indices) == 0 {
+		return
+	}
+	m.indexType = gl.UNSIGNED_SHORT
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+	gl.BufferDataUint16(gl.ELEMENT_ARRAY_BUFFER, indices, gl.STATIC_DRAW)
+	bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+}
+
+func (m *Mesh) setIndices32(indices []uint32) {
 	if len(indices) == 0 {
+		return
+	}
+	m.indexType = gl.UNSIGNED_INT
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, indexBytes(indices), gl.STATIC_DRAW)
+	bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+}
+
+// setVertexProperties stores the geometry's properties and prepares the index buffer.
+//	- vboSize 			Size of the vertex data in bytes
+//	- vertexFormat 		Storage of the vertex attributes; nil for float32 vertex data
+func (m *Mesh) setVertexProperties(vertexCount int, vboSize int, indexCount int, primitiveType PrimitiveType, vertexAttributes []string, vertexFormat []VertexAttrib, bufferLayout BufferLayout) {
+	assert.True(m.vbo.Value != 0, "Mesh was not initialized correctly")
+
+	if indexCount == 0 {
 		m.prepareIBO(false)
 		m.indexCount = vertexCount
 	} else {
 		m.prepareIBO(true)
-		m.indexCount = len(indices)
+		m.indexCount = indexCount
 	}
-	m.vertexCount = vertexCount
+	vertexSize := m.vertexSize
 	if vertexCount > 0 {
-		m.vertexSize = (len(vertices) / vertexCount) * 4
+		vertexSize = vboSize / vertexCount
 	}
+	if vertexSize != m.vertexSize || bufferLayout != m.bufferLayout ||
+		(bufferLayout == CompactBuffer && vertexCount != m.vertexCount) ||
+		!equalStringSlice(vertexAttributes, m.vertexAttributes) || !equalVertexFormat(vertexFormat, m.vertexFormat) {
+		m.layoutVersion++ // attribute pointers need to be recalculated
+	}
+
+	m.vertexCount = vertexCount
+	m.vertexSize = vertexSize
 	m.primitiveType = primitiveType
 	m.bufferLayout = bufferLayout
-	m.vboSize = len(vertices) * 4
+	m.vboSize = vboSize
 	m.vertexAttributes = vertexAttributes
+	m.vertexFormat = vertexFormat
 	m.primitiveCount = m.determinePrimitiveCount(m.indexCount, primitiveType)
+	m.invalidateBounds()
+}
 
-	AssertValidGeometry(m.material.sProgKey, vertexCount, vertices, indices, primitiveType, vertexAttributes)
+// SetGeometry is equivalent to SetVertexData and defines the mesh's geometry.
+// Uses 16bit indices if possible and 32bit indices otherwise.
+// The mesh's bounding box is calculated from the geometry's "position" attribute.
+func (m *Mesh) SetGeometry(geom *Geometry) {
+	switch {
+	case geom.vertexFormat != nil:
+		m.setRawVertexData(geom.vertexCount, geom.rawVertices, geom.wideIndices(), geom.primitiveType, geom.vertexFormat, geom.bufferLayout)
+	case geom.indices16 != nil: // upload without conversion
+		m.setVertexData(geom.vertexCount, geom.vertices, geom.indices16, geom.primitiveType, geom.vertexAttributes, geom.bufferLayout)
+	default:
+		m.setVertexData32(geom.vertexCount, geom.vertices, geom.indices, geom.primitiveType, geom.vertexAttributes, geom.bufferLayout)
+	}
+	m.calculateBounds(geom)
+}
 
-	usage := gl.Enum(gl.STATIC_DRAW)
+// calculateBounds determines the mesh's bounding box from the given geometry, unless the bounds were defined by the user.
+// The number of components of float32 vertex attributes is taken from the shader if the geometry doesn't define them.
+func (m *Mesh) calculateBounds(geom *Geometry) {
+	if !m.customBounds {
+		m.bounds, m.hasBounds = geometryBounds(geom, m.material.sProgKey)
+	}
+}
 
-	engine.lockBuffer(gl.ARRAY_BUFFER)
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
-	gl.BufferDataFloat32(gl.ARRAY_BUFFER, vertices, usage)
-	engine.unlockBuffer(gl.ARRAY_BUFFER)
+// Bounds returns the mesh's bounding box in model space.
+// Returns false if the bounds are unknown. Such meshes are never culled.
+func (m *Mesh) Bounds() (BoundingBox, bool) {
+	return m.bounds, m.hasBounds
+}
 
-	if len(indices) > 0 {
-		engine.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
-		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
-		gl.BufferDataUint16(gl.ELEMENT_ARRAY_BUFFER, indices, usage)
-		engine.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
-	}
+// SetBounds defines the mesh's bounding box in model space, which is used for culling.
+// The bounds are kept if the vertex data changes.
+// Required if the vertex data is changed with SetVertexSubData or modified within the shader.
+func (m *Mesh) SetBounds(bounds BoundingBox) {
+	m.bounds = bounds
+	m.hasBounds, m.customBounds = true, true
 }
 
-// SetGeometry is equivalent to SetVertexData and defines the mesh's geometry.
-func (m *Mesh) SetGeometry(geom *Geometry) {
-	m.SetVertexData(geom.vertexCount, geom.vertices, geom.indices, geom.primitiveType, geom.vertexAttributes, geom.bufferLayout)
+// ClearBounds removes the mesh's bounding box. The mesh will not be culled.
+// The bounds are recalculated the next time the vertex data is set.
+func (m *Mesh) ClearBounds() {
+	m.hasBounds, m.customBounds = false, false
 }
 
 func (m *Mesh) prepareIBO(required bool) {
@@ -148,14 +384,61 @@ func (m *Mesh) determinePrimitiveCount(indexCount int, primitiveType PrimitiveTy
 // 	- vertexOffset		Vertex offset.
 //  - vertices			Underlying vertex data that will overwrite existing buffers
 // Cannot change the underlying vertex buffer size.
+// Changes of dynamic buffers are collected and uploaded once before the mesh is drawn.
 func (m *Mesh) SetVertexSubData(vertexOffset int, vertices []float32) {
 	assert.True(vertexOffset >= 0 && vertexOffset < m.vertexCount, "Invalid vertex offset (out of range)")
 	assert.True(m.vertexSize > 0 && ((len(vertices)*4)%(m.vertexSize) == 0), "Invalid vertex data size")
 
-	engine.glSync.lockBuffer(gl.ARRAY_BUFFER)
+	if m.vboCopy != nil {
+		start := vertexOffset * m.vertexSize
+		if !assert.True(start+len(vertices)*4 <= len(m.vboCopy), "Invalid vertex data size (out of range)") {
+			return
+		}
+		for i, v := range vertices {
+			binary.LittleEndian.PutUint32(m.vboCopy[start+i*4:], math.Float32bits(v))
+		}
+		m.markVertexChange(start, start+len(vertices)*4)
+		m.invalidateBounds()
+		return
+	}
+
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 	gl.BufferSubDataFloat32(gl.ARRAY_BUFFER, vertexOffset*m.vertexSize, vertices)
-	engine.glSync.unlockBuffer(gl.ARRAY_BUFFER)
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.invalidateBounds()
+}
+
+// SetRawVertexSubData is equivalent to SetVertexSubData, but uses byte-level vertex data.
+func (m *Mesh) SetRawVertexSubData(vertexOffset int, vertices []byte) {
+	assert.True(vertexOffset >= 0 && vertexOffset < m.vertexCount, "Invalid vertex offset (out of range)")
+	assert.True(m.vertexSize > 0 && (len(vertices)%m.vertexSize == 0), "Invalid vertex data size")
+
+	if m.vboCopy != nil {
+		start := vertexOffset * m.vertexSize
+		if !assert.True(start+len(vertices) <= len(m.vboCopy), "Invalid vertex data size (out of range)") {
+			return
+		}
+		copy(m.vboCopy[start:], vertices)
+		m.markVertexChange(start, start+len(vertices))
+		m.invalidateBounds()
+		return
+	}
+
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, vertexOffset*m.vertexSize, vertices)
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	m.invalidateBounds()
+}
+
+// invalidateBounds disables culling after the vertex data was modified, unless the bounds were defined by the user.
+func (m *Mesh) invalidateBounds() {
+	if !m.customBounds {
+		m.hasBounds = false
+	}
 }
 
 // SetIndexSubData changes parts of the underlying index buffer.
@@ -163,15 +446,37 @@ func (m *Mesh) SetVertexSubData(vertexOffset int, vertices []float32) {
 //  - indices			Underlying index data that will overwrite existing buffers
 // Cannot change the underlying index buffer size.
 func (m *Mesh) SetIndexSubData(indexOffset int, indices []uint16) {
+	if m.indexType == gl.UNSIGNED_INT {
+		m.SetIndexSubData32(indexOffset, widenIndices(indices))
+		return
+	}
 	assert.True(m.ibo.Value != 0, "The mesh does not use indexed drawing")
 	assert.True(indexOffset >= 0 && indexOffset < m.indexCount, "Invalid index offset (out of range)")
 
 	// TODO: write assertion that checks that indices don't reference out-of-bounds vertices
 
-	engine.glSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
-	gl.BufferSubDataUint16(gl.ELEMENT_ARRAY_BUFFER, indexOffset, indices)
-	engine.glSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	gl.BufferSubDataUint16(gl.ELEMENT_ARRAY_BUFFER, indexOffset*2, indices)
+	bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+}
+
+// SetIndexSubData32 is equivalent to SetIndexSubData, but uses 32bit indices.
+// The indices are converted if the mesh uses 16bit indices.
+func (m *Mesh) SetIndexSubData32(indexOffset int, indices []uint32) {
+	if m.indexType != gl.UNSIGNED_INT {
+		m.SetIndexSubData(indexOffset, narrowIndices(indices))
+		return
+	}
+	assert.True(m.ibo.Value != 0, "The mesh does not use indexed drawing")
+	assert.True(indexOffset >= 0 && indexOffset < m.indexCount, "Invalid index offset (out of range)")
+
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+	gl.BufferSubData(gl.ELEMENT_ARRAY_BUFFER, indexOffset*4, indexBytes(indices))
+	bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
 }
 
 // ClearVertexData clears the underlying buffers.
@@ -191,43 +496,73 @@ func (m *Mesh) ClearVertexData() {
 
 // Draw renders the mesh.
 // The required material (shader, textures, uniforms) are applied and the buffers are bound for rendering.
+// If the render state has an active queue, drawing is deferred.
+// Meshes with known bounds are skipped if they are outside the camera's view (culling).
 func (m *Mesh) Draw(renderState *RenderState) {
 	if m.indexCount == 0 {
 		return
 	}
+	if renderState.culled(m) {
+		renderState.culledMeshes++
+		return
+	}
+	if renderState.queue != nil {
+		renderState.queue.enqueue(renderState, m)
+		return
+	}
+	m.draw(renderState, m.material)
+}
 
-	sProg := renderState.applyMaterial(m.material)
+func (m *Mesh) draw(renderState *RenderState, material *Material) {
+	sProg := renderState.applyMaterial(material)
 	if sProg == nil { // shader is not loaded
 		return
 	}
+	m.uploadVertexChanges()
 
-	sProg.configureVertexAttributes(m.vertexAttributes, true)
-	defer sProg.configureVertexAttributes(m.vertexAttributes, false)
-
-	engine.glSync.lockBuffer(gl.ARRAY_BUFFER)
+	layout := m.vertexLayout(sProg, renderState.sProgID)
 
+	bufferSync := sharedBufferSync()
+	bufferSync.lockBuffer(gl.ARRAY_BUFFER)
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
-	if m.bufferLayout == InterleavedBuffer {
-		m.configureInterleavedVertexAttributes(sProg)
-	} else {
-		m.configureCompactVertexAttributes(sProg)
+	enableAttribPointers(layout.vertex)
+	if m.ibo.Value != 0 {
+		bufferSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
 	}
 
+	m.drawCall()
+
 	if m.ibo.Value != 0 {
-		engine.glSync.lockBuffer(gl.ELEMENT_ARRAY_BUFFER)
-		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
-		gl.DrawElements(gl.Enum(m.primitiveType), m.indexCount, gl.UNSIGNED_SHORT, 0)
-		engine.glSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
-	} else {
-		gl.DrawArrays(gl.Enum(m.primitiveType), 0, m.indexCount)
+		bufferSync.unlockBuffer(gl.ELEMENT_ARRAY_BUFFER)
 	}
-	engine.glSync.unlockBuffer(gl.ARRAY_BUFFER)
+	bufferSync.unlockBuffer(gl.ARRAY_BUFFER)
+	disableAttribPointers(layout.vertex)
 
 	renderState.totalDrawCalls++
 	renderState.totalPrimitives += m.primitiveCount
 }
 
-func (m *Mesh) configureInterleavedVertexAttributes(sProg *shaderProgram) {
+func (m *Mesh) drawCall() {
+	if m.ibo.Value != 0 {
+		gl.DrawElements(gl.Enum(m.primitiveType), m.indexCount, m.indexType, 0)
+	} else {
+		gl.DrawArrays(gl.Enum(m.primitiveType), 0, m.indexCount)
+	}
+}
+
+// vertexAttribPointers returns the layout of the vertex buffer.
+func (m *Mesh) vertexAttribPointers(sProg *shaderProgram) []attribPointer {
+	if m.vertexFormat != nil {
+		return m.rawAttribPointers(sProg)
+	} else if m.bufferLayout == InterleavedBuffer {
+		return m.interleavedAttribPointers(sProg)
+	}
+	return m.compactAttribPointers(sProg)
+}
+
+func (m *Mesh) interleavedAttribPointers(sProg *shaderProgram) []attribPointer {
+	var pointers []attribPointer
 	offset := 0
 	stride := m.vertexSize
 	for _, vaName := range m.vertexAttributes {
@@ -237,12 +572,14 @@ func (m *Mesh) configureInterleavedVertexAttributes(sProg *shaderProgram) {
 		}
 
 		typProps := vaTypePropertyMapping[typ]
-		gl.VertexAttribPointer(loc, int(typProps.components), typProps.compType, false, stride, offset)
+		pointers = append(pointers, attribPointer{loc, int(typProps.components), gl.FLOAT, false, stride, offset})
 		offset += int(typProps.components) * 4
 	}
+	return pointers
 }
 
-func (m *Mesh) configureCompactVertexAttributes(sProg *shaderProgram) {
+func (m *Mesh) compactAttribPointers(sProg *shaderProgram) []attribPointer {
+	var pointers []attribPointer
 	vertexCount := m.vertexCount
 	component := 0
 	for _, vaName := range m.vertexAttributes {
@@ -253,9 +590,31 @@ func (m *Mesh) configureCompactVertexAttributes(sProg *shaderProgram) {
 
 		typProps := vaTypePropertyMapping[typ]
 		stride := int(typProps.components) * 4
-		gl.VertexAttribPointer(loc, int(typProps.components), typProps.compType, false, stride, vertexCount*component*4)
+		pointers = append(pointers, attribPointer{loc, int(typProps.components), gl.FLOAT, false, stride, vertexCount * component * 4})
 		component += int(typProps.components)
 	}
+	return pointers
+}
+
+func (m *Mesh) rawAttribPointers(sProg *shaderProgram) []attribPointer {
+	var pointers []attribPointer
+	offset := 0
+	for _, attr := range m.vertexFormat {
+		loc, typ := sProg.getAttribLocation(attr.Name)
+		if !assert.True(typ != 0, "Unsupported vertex attribute %q", attr.Name) {
+			continue
+		}
+
+		stride := m.vertexSize
+		attrOffset := offset
+		if m.bufferLayout == CompactBuffer {
+			stride = attr.Size()
+			attrOffset = m.vertexCount * offset
+		}
+		pointers = append(pointers, attribPointer{loc, attr.Components, attr.Type, attr.Normalized, stride, attrOffset})
+		offset += attr.Size()
+	}
+	return pointers
 }
 
 // Info returns the number of primitives, vertices and indices of the mesh.
@@ -270,11 +629,11 @@ func (m *Mesh) String() string {
 		"Primitives  %d (%s, %s)\n"+
 		"Vertices    %d\n"+
 		"Indices     %d\n"+
-		"Vertex size %d (%d attributes, %d bytes total)\n"+
+		"Vertex size %d bytes (%d attributes)\n"+
 		"VBO size    %d bytes",
 		m.primitiveCount, m.primitiveType.String(), m.bufferLayout.String(),
 		m.vertexCount,
 		m.indexCount,
-		m.vertexSize/4, len(m.vertexAttributes), m.vertexSize,
+		m.vertexSize, len(m.vertexAttributes),
 		m.vboSize)
 }
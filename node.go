@@ -0,0 +1,237 @@
+package nora
+
+import (
+	"time"
+
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/vmath"
+)
+
+// Node is an element of a scene graph.
+// Its transformation is relative to the parent node.
+// Components (Drawable and/or Updateable objects) are drawn and updated together with the node,
+// using the node's transformation.
+type Node struct {
+	Transform
+	name       string
+	visible    bool
+	parent     *Node
+	children   []*Node
+	components []interface{}
+
+	world            vmath.Mat4f // cached world transformation
+	worldValid       bool
+	worldDirtyCount  int // incremented every time the world transformation changes
+	localDirtyCount  int // dirty count of the local transformation used for the world transformation
+	parentDirtyCount int // dirty count of the parent's world transformation used for the world transformation
+}
+
+// NewNode creates a new, visible node without parent.
+func NewNode(name string) *Node {
+	n := &Node{
+		name:    name,
+		visible: true,
+	}
+	n.ClearTransform()
+	return n
+}
+
+// Name returns the node's name.
+func (n *Node) Name() string {
+	return n.name
+}
+
+// SetName changes the node's name.
+func (n *Node) SetName(name string) {
+	n.name = name
+}
+
+// Visible returns true if the node is drawn.
+func (n *Node) Visible() bool {
+	return n.visible
+}
+
+// SetVisible shows or hides the node.
+// Hidden nodes don't draw their components and children, but are still updated.
+func (n *Node) SetVisible(visible bool) {
+	n.visible = visible
+}
+
+// Parent returns the parent node; nil if the node is a root.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Children returns all child nodes.
+// The caller must not modify the returned slice.
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// AddChild attaches a node as the last child.
+// If the node already has a parent, it is removed from there first.
+func (n *Node) AddChild(child *Node) {
+	child.SetParent(n)
+}
+
+// RemoveChild detaches a child node.
+// Returns false if the node is not a child of this node.
+func (n *Node) RemoveChild(child *Node) bool {
+	if child.parent != n {
+		return false
+	}
+	child.SetParent(nil)
+	return true
+}
+
+// SetParent moves the node to a new parent; nil detaches the node.
+// The local transformation is kept, the world transformation changes accordingly.
+func (n *Node) SetParent(parent *Node) {
+	if n.parent == parent {
+		return
+	}
+	for p := parent; p != nil; p = p.parent {
+		if !assert.True(p != n, "Node %q can't be attached to itself or its descendants", n.name) {
+			return
+		}
+	}
+
+	if old := n.parent; old != nil {
+		for i, c := range old.children {
+			if c == n {
+				old.children = append(old.children[:i], old.children[i+1:]...)
+				break
+			}
+		}
+	}
+	n.parent = parent
+	if parent != nil {
+		parent.children = append(parent.children, n)
+	}
+	n.worldValid = false
+}
+
+// Root returns the topmost ancestor; the node itself if it has no parent.
+func (n *Node) Root() *Node {
+	root := n
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// Find returns the first node within the hierarchy (including this node) with the given name; nil if there is none.
+func (n *Node) Find(name string) *Node {
+	if n.name == name {
+		return n
+	}
+	for _, child := range n.children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// AddComponent attaches an object that is drawn and/or updated with the node.
+// Components must implement Drawable, Updateable, or both.
+func (n *Node) AddComponent(component interface{}) {
+	_, drawable := component.(Drawable)
+	_, updateable := component.(Updateable)
+	if !assert.True(drawable || updateable, "Component %T is neither drawable nor updateable", component) {
+		return
+	}
+	n.components = append(n.components, component)
+}
+
+// RemoveComponent detaches a component.
+// Returns false if the component is not attached to this node.
+func (n *Node) RemoveComponent(component interface{}) bool {
+	for i, c := range n.components {
+		if c == component {
+			n.components = append(n.components[:i], n.components[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Components returns all attached components.
+// The caller must not modify the returned slice.
+func (n *Node) Components() []interface{} {
+	return n.components
+}
+
+// WorldTransform returns the node's model->world transformation, including all parent transformations.
+// The result is cached and only recalculated if the node or one of its parents changed.
+// The cache is only used for queries like this one; Draw uses the render state's transform stack.
+func (n *Node) WorldTransform() vmath.Mat4f {
+	local := n.GetTransform()
+
+	parentWorld := vmath.Ident4f()
+	parentDirtyCount := 0
+	if n.parent != nil {
+		parentWorld = n.parent.WorldTransform()
+		parentDirtyCount = n.parent.worldDirtyCount
+	}
+
+	if n.worldValid && n.localDirtyCount == n.dirtyCount && n.parentDirtyCount == parentDirtyCount {
+		return n.world
+	}
+	n.world = parentWorld.Mul(local)
+	n.worldValid = true
+	n.worldDirtyCount++
+	n.localDirtyCount = n.dirtyCount
+	n.parentDirtyCount = parentDirtyCount
+	return n.world
+}
+
+// WorldPosition returns the node's position in world space.
+func (n *Node) WorldPosition() vmath.Vec3f {
+	return n.WorldTransform().MulVec(vmath.Vec4f{0, 0, 0, 1}).XYZ()
+}
+
+// Update updates all updateable components, followed by all children.
+func (n *Node) Update(elapsed time.Duration) {
+	for _, c := range n.components {
+		if u, ok := c.(Updateable); ok {
+			u.Update(elapsed)
+		}
+	}
+	for _, child := range n.children {
+		child.Update(elapsed)
+	}
+}
+
+// Draw applies the node's transformation and draws all drawable components, followed by all children.
+// The transformation is pushed onto the transform stack; the cached world transformation is not used.
+// Does nothing if the node is hidden.
+func (n *Node) Draw(renderState *RenderState) {
+	if !n.visible {
+		return
+	}
+	renderState.TransformStack.PushMulRight(n.GetTransform())
+	for _, c := range n.components {
+		if d, ok := c.(Drawable); ok {
+			d.Draw(renderState)
+		}
+	}
+	for _, child := range n.children {
+		child.Draw(renderState)
+	}
+	renderState.TransformStack.Pop()
+}
+
+// Destroy destroys all destroyable components and child nodes, and detaches the node from its parent.
+func (n *Node) Destroy() {
+	for _, c := range n.components {
+		if d, ok := c.(Destroyable); ok {
+			d.Destroy()
+		}
+	}
+	for len(n.children) > 0 {
+		n.children[len(n.children)-1].Destroy()
+	}
+	n.components = nil
+	n.SetParent(nil)
+}
@@ -0,0 +1,225 @@
+// Package noratest provides utilities for rendering drawables offscreen
+// and comparing the results against reference images (golden-image tests).
+package noratest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/maja42/nora"
+	"github.com/maja42/nora/builtin/shader"
+	ncolor "github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+)
+
+// UpdateEnvVar is the name of the environment variable that, if set to a non-empty value,
+// causes AssertGolden to overwrite the stored reference images instead of comparing against them.
+const UpdateEnvVar = "NORA_UPDATE_GOLDEN"
+
+// TB is the subset of testing.TB used by the harness.
+type TB interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Settings configure the test harness.
+type Settings struct {
+	// Size of the offscreen framebuffer.
+	// Defaults to 256 x 256.
+	Size vmath.Vec2i
+	// Directory containing the builtin shaders.
+	// If empty, no shaders are loaded.
+	ShaderDir string
+	// Directory containing the reference images.
+	GoldenDir string
+	// Directory for storing actual and diff images of failed comparisons.
+	// Defaults to GoldenDir/failures.
+	FailureDir string
+	// Maximum difference per color channel for two pixels to be considered equal.
+	Tolerance uint8
+	// Background color
+	ClearColor ncolor.Color
+}
+
+// Harness renders drawables offscreen and compares them against reference images.
+type Harness struct {
+	settings Settings
+	engine   *nora.Engine
+}
+
+// The library can only be initialized once per process (OpenGL is bound to a single render thread).
+// It is therefore shared by all harnesses and never de-initialized.
+var initOnce sync.Once
+var initErr error
+
+// New initializes the library (if not done already) and creates a new offscreen engine.
+// Only one harness can exist at a time. Call Destroy() afterwards to free all resources.
+func New(settings Settings) (*Harness, error) {
+	if settings.Size.IsZero() {
+		settings.Size = vmath.Vec2i{256, 256}
+	}
+	if settings.FailureDir == "" {
+		settings.FailureDir = filepath.Join(settings.GoldenDir, "failures")
+	}
+
+	initOnce.Do(func() {
+		initErr = nora.Init()
+	})
+	if initErr != nil {
+		return nil, initErr
+	}
+	engine, err := nora.CreateOffscreen(nora.Settings{
+		WindowTitle: "noratest",
+		WindowSize:  settings.Size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.ShaderDir != "" {
+		if err := engine.Shaders.LoadAll(shader.Builtins(settings.ShaderDir)); err != nil {
+			engine.Destroy()
+			return nil, fmt.Errorf("load builtin shaders: %w", err)
+		}
+	}
+	engine.SetClearColor(settings.ClearColor)
+
+	return &Harness{
+		settings: settings,
+		engine:   engine,
+	}, nil
+}
+
+// Destroy shuts down the engine.
+// The library stays initialized for subsequent harnesses.
+func (h *Harness) Destroy() {
+	h.engine.Destroy()
+}
+
+// Engine returns the underlying offscreen engine.
+// Can be used for loading additional resources or to configure the camera.
+func (h *Harness) Engine() *nora.Engine {
+	return h.engine
+}
+
+// Render draws a single frame containing the given drawables and returns the result.
+func (h *Harness) Render(drawables ...nora.Drawable) *image.RGBA {
+	h.engine.RenderFrames(1, func(_ time.Duration, renderState *nora.RenderState) bool {
+		for _, d := range drawables {
+			d.Draw(renderState)
+		}
+		return true
+	})
+	return h.engine.Screenshot()
+}
+
+// AssertGolden renders the given drawables and compares the result against the reference image with the given name.
+// On failure, the actual and the diff image are stored in the failure directory.
+// If the environment variable NORA_UPDATE_GOLDEN is set, the reference image is overwritten instead.
+func (h *Harness) AssertGolden(t TB, name string, drawables ...nora.Drawable) bool {
+	t.Helper()
+	actual := h.Render(drawables...)
+	goldenPath := filepath.Join(h.settings.GoldenDir, name+".png")
+
+	if os.Getenv(UpdateEnvVar) != "" {
+		if err := writeImage(goldenPath, actual); err != nil {
+			t.Fatalf("Failed to update reference image %q: %s", goldenPath, err)
+			return false
+		}
+		t.Logf("Updated reference image %q", goldenPath)
+		return true
+	}
+
+	expected, err := LoadPNG(goldenPath)
+	if err != nil {
+		t.Errorf("Failed to load reference image (set %s=1 to create it): %s", UpdateEnvVar, err)
+		return false
+	}
+
+	mismatches, diff := Compare(actual, expected, h.settings.Tolerance)
+	if mismatches == 0 {
+		return true
+	}
+
+	actualPath := filepath.Join(h.settings.FailureDir, name+".actual.png")
+	diffPath := filepath.Join(h.settings.FailureDir, name+".diff.png")
+	if err := writeImage(actualPath, actual); err != nil {
+		t.Logf("Failed to store actual image: %s", err)
+	}
+	if err := writeImage(diffPath, diff); err != nil {
+		t.Logf("Failed to store diff image: %s", err)
+	}
+	t.Errorf("Rendered image differs from %q in %d pixels (tolerance %d). See %q and %q",
+		goldenPath, mismatches, h.settings.Tolerance, actualPath, diffPath)
+	return false
+}
+
+// Compare compares two images pixel by pixel.
+// Two pixels are considered equal if none of their color channels differ by more than the given tolerance.
+// Returns the number of mismatching pixels and a diff image, highlighting mismatches in red.
+// If the image sizes differ, all pixels outside the common area are mismatches.
+func Compare(actual, expected image.Image, tolerance uint8) (int, *image.RGBA) {
+	bounds := actual.Bounds().Union(expected.Bounds())
+	diff := image.NewRGBA(bounds)
+	mismatches := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Point{X: x, Y: y}
+			if !p.In(actual.Bounds()) || !p.In(expected.Bounds()) {
+				diff.Set(x, y, color.RGBA{R: 255, A: 255})
+				mismatches++
+				continue
+			}
+			a := color.RGBAModel.Convert(actual.At(x, y)).(color.RGBA)
+			e := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+
+			if channelDiff(a.R, e.R) > tolerance || channelDiff(a.G, e.G) > tolerance ||
+				channelDiff(a.B, e.B) > tolerance || channelDiff(a.A, e.A) > tolerance {
+				diff.Set(x, y, color.RGBA{R: 255, A: 255})
+				mismatches++
+				continue
+			}
+			// matching pixels are shown dimmed, to provide some context
+			gray := uint8((uint16(e.R) + uint16(e.G) + uint16(e.B)) / 3 / 4)
+			diff.Set(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	return mismatches, diff
+}
+
+// LoadPNG reads a png image from the filesystem.
+func LoadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q: %w", path, err)
+	}
+	return img, nil
+}
+
+func writeImage(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return nora.SavePNG(path, img)
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,67 @@
+package noratest_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/maja42/nora/noratest"
+)
+
+func filled(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	gray := color.RGBA{R: 100, G: 100, B: 100, A: 255}
+	slightlyOff := filled(4, 4, gray)
+	slightlyOff.SetRGBA(1, 1, color.RGBA{R: 103, G: 100, B: 100, A: 255})
+	wayOff := filled(4, 4, gray)
+	wayOff.SetRGBA(0, 0, color.RGBA{R: 255, G: 100, B: 100, A: 255})
+	wayOff.SetRGBA(3, 2, color.RGBA{R: 100, G: 100, B: 100, A: 0})
+
+	tests := []struct {
+		name       string
+		actual     image.Image
+		expected   image.Image
+		tolerance  uint8
+		mismatches int
+	}{
+		{"identical", filled(4, 4, gray), filled(4, 4, gray), 0, 0},
+		{"within tolerance", slightlyOff, filled(4, 4, gray), 3, 0},
+		{"exceeds tolerance", slightlyOff, filled(4, 4, gray), 2, 1},
+		{"multiple channels", wayOff, filled(4, 4, gray), 10, 2},
+		{"larger actual", filled(5, 4, gray), filled(4, 4, gray), 0, 4},
+		{"larger expected", filled(4, 4, gray), filled(4, 6, gray), 0, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches, diff := noratest.Compare(tt.actual, tt.expected, tt.tolerance)
+			if mismatches != tt.mismatches {
+				t.Errorf("got %d mismatches, want %d", mismatches, tt.mismatches)
+			}
+			bounds := tt.actual.Bounds().Union(tt.expected.Bounds())
+			if diff.Bounds() != bounds {
+				t.Errorf("diff image has bounds %v, want %v", diff.Bounds(), bounds)
+			}
+
+			red := 0
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if diff.RGBAAt(x, y) == (color.RGBA{R: 255, A: 255}) {
+						red++
+					}
+				}
+			}
+			if red != tt.mismatches {
+				t.Errorf("diff image highlights %d pixels, want %d", red, tt.mismatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package nora
+
+import (
+	"math"
+
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+// PerspectiveCamera is a 3D camera with perspective projection.
+// By default, it is located at the origin and looks along the negative z-axis, with the y-axis pointing upwards.
+type PerspectiveCamera struct {
+	pos         vmath.Vec3f
+	orientation vmath.Quat
+
+	fovY        float32 // vertical field of view in radians
+	aspectRatio float32
+
+	// near/far-plane are the (positive) distances to the camera
+	nearPlane float32
+	farPlane  float32
+
+	viewMatrix      vmath.Mat4f
+	projMatrix      vmath.Mat4f
+	vpMatrix        vmath.Mat4f
+	inverseVPMatrix vmath.Mat4f
+
+	dirtyCount int
+}
+
+// NewPerspectiveCamera creates a new perspective camera.
+//	- fovY 			Vertical field of view in radians
+//	- aspectRatio 	Width / height of the viewport
+//	- near, far 	Distances of the clipping planes to the camera (0 < near < far)
+func NewPerspectiveCamera(fovY, aspectRatio, near, far float32) *PerspectiveCamera {
+	cam := &PerspectiveCamera{
+		orientation: vmath.IdentQuat(),
+		fovY:        fovY,
+		aspectRatio: aspectRatio,
+		nearPlane:   near,
+		farPlane:    far,
+	}
+	assert.True(fovY > 0 && fovY < math.Pi, "Invalid field of view %f (must be within ]0, pi[)", fovY)
+	assert.True(aspectRatio > 0, "Invalid aspect ratio %f (must be >0)", aspectRatio)
+	assert.True(near > 0 && near < far, "Invalid clipping planes %f/%f (must be 0 < near < far)", near, far)
+	cam.updateVPMatrices()
+	return cam
+}
+
+// CopyFrom applies the properties from another camera to this camera.
+func (c *PerspectiveCamera) CopyFrom(other *PerspectiveCamera) {
+	oldDirty := c.dirtyCount
+	*c = *other
+	c.dirtyCount = oldDirty + 1
+}
+
+// Copy creates a duplicate of this camera
+func (c PerspectiveCamera) Copy() *PerspectiveCamera {
+	c.dirtyCount = 0
+	return &c
+}
+
+// Position returns the camera's world position.
+func (c *PerspectiveCamera) Position() vmath.Vec3f {
+	return c.pos
+}
+
+// Orientation returns the camera's rotation.
+func (c *PerspectiveCamera) Orientation() vmath.Quat {
+	return c.orientation
+}
+
+// FieldOfView returns the vertical field of view in radians.
+func (c *PerspectiveCamera) FieldOfView() float32 {
+	return c.fovY
+}
+
+// AspectRatio returns the camera's aspect ratio.
+func (c *PerspectiveCamera) AspectRatio() float32 {
+	return c.aspectRatio
+}
+
+// Near returns the distance between the camera and its near plane.
+func (c *PerspectiveCamera) Near() float32 {
+	return c.nearPlane
+}
+
+// Far returns the distance between the camera and its far plane.
+func (c *PerspectiveCamera) Far() float32 {
+	return c.farPlane
+}
+
+// Forward returns the (normalized) direction the camera is looking at, in world coordinates.
+func (c *PerspectiveCamera) Forward() vmath.Vec3f {
+	return c.rotate(vmath.Vec3f{0, 0, -1})
+}
+
+// Right returns the (normalized) direction to the camera's right, in world coordinates.
+func (c *PerspectiveCamera) Right() vmath.Vec3f {
+	return c.rotate(vmath.Vec3f{1, 0, 0})
+}
+
+// Up returns the (normalized) upwards direction of the camera, in world coordinates.
+func (c *PerspectiveCamera) Up() vmath.Vec3f {
+	return c.rotate(vmath.Vec3f{0, 1, 0})
+}
+
+// rotate applies the camera's orientation to the given direction.
+func (c *PerspectiveCamera) rotate(dir vmath.Vec3f) vmath.Vec3f {
+	rot := vmath.Mat4fFromRotationTranslationScaleOrigin(c.orientation, vmath.Vec3f{}, vmath.Vec3f{1, 1, 1}, vmath.Vec3f{})
+	return rot.MulVec(dir.Vec4f(0)).XYZ()
+}
+
+// SetPosition sets the camera's world position.
+func (c *PerspectiveCamera) SetPosition(pos vmath.Vec3f) {
+	c.pos = pos
+	c.updateVPMatrices()
+}
+
+// SetPositionXYZ sets the camera's world position.
+func (c *PerspectiveCamera) SetPositionXYZ(x, y, z float32) {
+	c.pos = vmath.Vec3f{x, y, z}
+	c.updateVPMatrices()
+}
+
+// Move translates the camera in world space.
+func (c *PerspectiveCamera) Move(vec vmath.Vec3f) {
+	c.pos = c.pos.Add(vec)
+	c.updateVPMatrices()
+}
+
+// MoveRelative translates the camera along its own axes.
+//	- right 	Distance to the right (negative: left)
+//	- up 		Distance upwards (negative: downwards)
+//	- forward 	Distance forwards (negative: backwards)
+func (c *PerspectiveCamera) MoveRelative(right, up, forward float32) {
+	c.pos = c.pos.
+		Add(c.Right().MulScalar(right)).
+		Add(c.Up().MulScalar(up)).
+		Add(c.Forward().MulScalar(forward))
+	c.updateVPMatrices()
+}
+
+// SetOrientation sets the camera's rotation.
+func (c *PerspectiveCamera) SetOrientation(orientation vmath.Quat) {
+	c.orientation = orientation.Normalize()
+	c.updateVPMatrices()
+}
+
+// RotateX rotates the camera along its own x-axis (pitch).
+func (c *PerspectiveCamera) RotateX(rad float32) {
+	c.orientation = c.orientation.RotateX(rad)
+	c.updateVPMatrices()
+}
+
+// RotateY rotates the camera along its own y-axis (yaw).
+func (c *PerspectiveCamera) RotateY(rad float32) {
+	c.orientation = c.orientation.RotateY(rad)
+	c.updateVPMatrices()
+}
+
+// RotateZ rotates the camera along its own z-axis (roll).
+func (c *PerspectiveCamera) RotateZ(rad float32) {
+	c.orientation = c.orientation.RotateZ(rad)
+	c.updateVPMatrices()
+}
+
+// LookAt rotates the camera towards the given world position.
+// The camera stays upright (no roll); the world's y-axis is considered as "up".
+// Looking straight up- or downwards keeps the current yaw.
+func (c *PerspectiveCamera) LookAt(target vmath.Vec3f) {
+	dir := target.Sub(c.pos)
+	if !assert.True(!dir.IsZero(), "Camera can't look at its own position") {
+		return
+	}
+	dir = dir.Normalize()
+
+	pitch := math32.Asin(vmath.Clampf(dir[1], -1, 1))
+	var yaw float32
+	if math32.Abs(dir[0]) > 1e-6 || math32.Abs(dir[2]) > 1e-6 {
+		yaw = math32.Atan2(-dir[0], -dir[2])
+	} else { // looking straight up/down; keep the current yaw
+		fwd := c.Forward()
+		yaw = math32.Atan2(-fwd[0], -fwd[2])
+	}
+
+	yawRot := vmath.QuatFromAxisAngle(vmath.Vec3f{0, 1, 0}, yaw)
+	pitchRot := vmath.QuatFromAxisAngle(vmath.Vec3f{1, 0, 0}, pitch)
+	c.orientation = pitchRot.Rotate(yawRot).Normalize() // yaw * pitch; pitches around the already yawed x-axis
+	c.updateVPMatrices()
+}
+
+// SetFieldOfView changes the vertical field of view (in radians).
+func (c *PerspectiveCamera) SetFieldOfView(fovY float32) {
+	if !assert.True(fovY > 0 && fovY < math.Pi, "Invalid field of view %f (must be within ]0, pi[)", fovY) {
+		return
+	}
+	c.fovY = fovY
+	c.updateVPMatrices()
+}
+
+// SetAspectRatio changes the camera's aspect ratio.
+func (c *PerspectiveCamera) SetAspectRatio(aspectRatio float32) {
+	if !assert.True(aspectRatio > 0, "Invalid aspect ratio %f (must be >0)", aspectRatio) {
+		return
+	}
+	c.aspectRatio = aspectRatio
+	c.updateVPMatrices()
+}
+
+// SetClippingPlanes changes the distances of the near and far plane.
+func (c *PerspectiveCamera) SetClippingPlanes(near, far float32) {
+	if !assert.True(near > 0 && near < far, "Invalid clipping planes %f/%f (must be 0 < near < far)", near, far) {
+		return
+	}
+	c.nearPlane = near
+	c.farPlane = far
+	c.updateVPMatrices()
+}
+
+func (c *PerspectiveCamera) updateVPMatrices() {
+	world := vmath.Mat4fFromRotationTranslationScaleOrigin(c.orientation, c.pos, vmath.Vec3f{1, 1, 1}, vmath.Vec3f{})
+	c.viewMatrix, _ = world.Inverse()
+	c.projMatrix = vmath.Perspective(c.fovY, c.aspectRatio, c.nearPlane, c.farPlane)
+	c.vpMatrix = c.projMatrix.Mul(c.viewMatrix)
+	c.inverseVPMatrix, _ = c.vpMatrix.Inverse()
+	c.dirtyCount++
+}
+
+// ClipSpaceToWorldSpace converts 3D clip space [-1, +1] into 3D world coordinates.
+// A z-coordinate of -1 refers to the near plane, +1 to the far plane.
+func (c *PerspectiveCamera) ClipSpaceToWorldSpace(clipSpace vmath.Vec3f) vmath.Vec3f {
+	worldSpace := c.inverseVPMatrix.MulVec(clipSpace.Vec4f(1))
+	return worldSpace.XYZ().DivScalar(worldSpace[3])
+}
+
+// WorldSpaceToClipSpace converts 3D world coordinates into 3D clip space [-1, +1].
+// Positions outside the camera's frustum are outside of the [-1, +1] range.
+func (c *PerspectiveCamera) WorldSpaceToClipSpace(worldSpace vmath.Vec3f) vmath.Vec3f {
+	clipSpace := c.vpMatrix.MulVec(worldSpace.Vec4f(1))
+	return clipSpace.XYZ().DivScalar(clipSpace[3])
+}
+
+// ClipSpaceToRay returns the ray through the given 2D clip space position [-1, +1].
+// The ray starts at the near plane and has a normalized direction.
+// Can be used for picking objects with the mouse.
+func (c *PerspectiveCamera) ClipSpaceToRay(clipSpace vmath.Vec2f) (origin, direction vmath.Vec3f) {
+	origin = c.ClipSpaceToWorldSpace(clipSpace.Vec3f(-1))
+	far := c.ClipSpaceToWorldSpace(clipSpace.Vec3f(1))
+	return origin, far.Sub(origin).Normalize()
+}
+
+// Matrix returns the view-projection matrix and its change-counter.
+// The change-counter gets incremented every time camera properties are modified.
+func (c *PerspectiveCamera) Matrix() (vmath.Mat4f, int) {
+	return c.vpMatrix, c.dirtyCount
+}
+
+// InverseMatrix returns the inverse view-projection matrix and its change-counter.
+// The change-counter gets incremented every time camera properties are modified.
+func (c *PerspectiveCamera) InverseMatrix() (vmath.Mat4f, int) {
+	return c.inverseVPMatrix, c.dirtyCount
+}
+
+// ViewMatrix returns the view matrix, transforming world space into camera space.
+func (c *PerspectiveCamera) ViewMatrix() vmath.Mat4f {
+	return c.viewMatrix
+}
+
+// ProjectionMatrix returns the projection matrix, transforming camera space into clip space.
+func (c *PerspectiveCamera) ProjectionMatrix() vmath.Mat4f {
+	return c.projMatrix
+}
+
+// DirtyCount returns a counter that is incremented every time the camera is modified.
+// Can be used to check for modifications / if camera-dependent updates are needed.
+func (c *PerspectiveCamera) DirtyCount() int {
+	return c.dirtyCount
+}
@@ -0,0 +1,42 @@
+package nora
+
+import (
+	"testing"
+
+	"github.com/maja42/vmath"
+	"github.com/maja42/vmath/math32"
+)
+
+func TestPerspectiveCameraLookAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		pos    vmath.Vec3f
+		target vmath.Vec3f
+	}{
+		{"default direction", vmath.Vec3f{0, 0, 0}, vmath.Vec3f{0, 0, -5}},
+		{"behind", vmath.Vec3f{0, 0, 0}, vmath.Vec3f{0, 0, 5}},
+		{"right", vmath.Vec3f{1, 2, 3}, vmath.Vec3f{6, 2, 3}},
+		{"yaw and pitch", vmath.Vec3f{0, 0, 0}, vmath.Vec3f{-3, 2, -4}},
+		{"downwards", vmath.Vec3f{4, 10, -2}, vmath.Vec3f{0, 0, 0}},
+		{"straight up", vmath.Vec3f{0, 0, 0}, vmath.Vec3f{0, 7, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cam := NewPerspectiveCamera(1, 1, 0.1, 100)
+			cam.SetPosition(tt.pos)
+			cam.LookAt(tt.target)
+
+			want := tt.target.Sub(tt.pos).Normalize()
+			if fwd := cam.Forward(); fwd.Sub(want).Length() > 1e-5 {
+				t.Errorf("forward is %v, want %v", fwd, want)
+			}
+			if right := cam.Right(); math32.Abs(right[1]) > 1e-5 {
+				t.Errorf("camera is rolled; right is %v", right)
+			}
+			// the target is projected onto the screen's center
+			if clip := cam.WorldSpaceToClipSpace(tt.target); clip.XY().Length() > 1e-4 {
+				t.Errorf("target is at clip space %v, want the center", clip)
+			}
+		})
+	}
+}
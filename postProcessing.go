@@ -0,0 +1,219 @@
+package nora
+
+import (
+	"fmt"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/vmath"
+	"go.uber.org/atomic"
+)
+
+// PostProcessInputUniformName contains the name of the sampler uniform that receives the output of the previous pass
+const PostProcessInputUniformName = "sampler"
+
+// PostProcessTexelSizeUniformName contains the name of the shader uniform (vec2) that receives the size of a single texel (1/resolution), if supported by the pass
+const PostProcessTexelSizeUniformName = "texelSize"
+
+// PostProcessSceneTextureKey refers to the unprocessed scene.
+// Can be bound to post-processing passes that need to access the original image (eg. to combine it with a blurred version).
+// The key is a placeholder: while a pass is rendered, it is replaced by the scene texture of the chain the pass belongs to.
+const PostProcessSceneTextureKey = TextureKey("nora:post-scene")
+
+// postProcessChainSeq provides unique ids for the textures of post-processing chains,
+// because engines can share a texture store.
+var postProcessChainSeq atomic.Uint32
+
+// PostProcessChain renders the scene into an offscreen buffer and passes it through an ordered list of fullscreen shader passes.
+// Each pass is a material; its shader receives the output of the previous pass via the "sampler" uniform.
+// Vertex shaders of passes receive the vertex attributes "position" and "texCoord" and need to apply the "vpMatrix".
+//
+// Since passes refer to shader programs via keys, they are hot-reloaded together with all other shaders.
+type PostProcessChain struct {
+	passes []*Material
+
+	id      uint32 // unique id for the texture keys; 0 if not assigned yet
+	scene   *RenderTarget
+	targets [2]*RenderTarget
+	quad    *Mesh
+	camera  *OrthoCamera // identity transformation
+}
+
+// Add appends a new pass at the end of the chain.
+func (c *PostProcessChain) Add(pass *Material) {
+	c.passes = append(c.passes, pass)
+}
+
+// Insert adds a new pass at the given position.
+func (c *PostProcessChain) Insert(idx int, pass *Material) {
+	if !assert.True(idx >= 0 && idx <= len(c.passes), "index out of range") {
+		return
+	}
+	c.passes = append(c.passes, nil)
+	copy(c.passes[idx+1:], c.passes[idx:])
+	c.passes[idx] = pass
+}
+
+// Remove removes a pass from the chain.
+// Returns false if the pass is not part of the chain.
+func (c *PostProcessChain) Remove(pass *Material) bool {
+	for idx, p := range c.passes {
+		if p == pass {
+			c.passes = append(c.passes[:idx], c.passes[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all passes. Post-processing is disabled afterwards.
+func (c *PostProcessChain) Clear() {
+	c.passes = nil
+}
+
+// Passes returns all passes in the order they are applied.
+// The caller must not modify the returned slice.
+func (c *PostProcessChain) Passes() []*Material {
+	return c.passes
+}
+
+// Enabled returns true if there is at least one pass.
+func (c *PostProcessChain) Enabled() bool {
+	return len(c.passes) > 0
+}
+
+// Destroy frees all GPU resources.
+// The chain can still be used afterwards; resources are created again on demand.
+func (c *PostProcessChain) Destroy() {
+	if c.scene == nil {
+		return
+	}
+	c.scene.Destroy()
+	for _, t := range c.targets {
+		t.Destroy()
+	}
+	c.quad.Destroy()
+	c.scene, c.targets, c.quad = nil, [2]*RenderTarget{}, nil
+}
+
+// prepare ensures that all offscreen buffers exist and have the given size.
+func (c *PostProcessChain) prepare(size vmath.Vec2i) bool {
+	if c.scene == nil {
+		return c.create(size)
+	}
+	ok := assert.True(c.scene.Resize(size) == nil, "Failed to resize post-processing buffers")
+	for _, t := range c.targets {
+		ok = ok && assert.True(t.Resize(size) == nil, "Failed to resize post-processing buffers")
+	}
+	return ok
+}
+
+func (c *PostProcessChain) create(size vmath.Vec2i) bool {
+	props := TextureProperties{
+		MinFilter: gl.LINEAR,
+		MagFilter: gl.LINEAR,
+		WrapS:     gl.CLAMP_TO_EDGE,
+		WrapT:     gl.CLAMP_TO_EDGE,
+	}
+
+	if c.id == 0 {
+		c.id = postProcessChainSeq.Inc()
+	}
+	sceneKey := TextureKey(fmt.Sprintf("%s#%d", PostProcessSceneTextureKey, c.id))
+	targetKeys := [2]TextureKey{
+		TextureKey(fmt.Sprintf("nora:post-ping#%d", c.id)),
+		TextureKey(fmt.Sprintf("nora:post-pong#%d", c.id)),
+	}
+
+	var err error
+	if c.scene, err = NewRenderTarget(sceneKey, size, props); err != nil {
+		assert.Fail("Failed to create post-processing buffers: %s", err)
+		c.scene = nil
+		return false
+	}
+	for idx, key := range targetKeys {
+		if c.targets[idx], err = NewRenderTarget(key, size, props); err != nil {
+			assert.Fail("Failed to create post-processing buffers: %s", err)
+			for i := 0; i < idx; i++ {
+				c.targets[i].Destroy()
+			}
+			c.scene.Destroy()
+			c.scene, c.targets = nil, [2]*RenderTarget{}
+			return false
+		}
+	}
+
+	/* counter-clockwise
+	   3 - 2
+	   | / |
+	   0 - 1
+	*/
+	vertices := []float32{
+		/*xy*/ -1, -1 /*uv*/, 0, 0, // 0
+		/*xy*/ +1, -1 /*uv*/, 1, 0, // 1
+		/*xy*/ +1, +1 /*uv*/, 1, 1, // 2
+		/*xy*/ -1, +1 /*uv*/, 0, 1, // 3
+	}
+	c.quad = NewMesh(NewMaterial(""))
+	c.quad.SetVertexData(4, vertices, []uint16{0, 1, 2, 2, 3, 0}, gl.TRIANGLES, []string{"position", "texCoord"}, InterleavedBuffer)
+	c.camera = NewOrthoCamera()
+	return true
+}
+
+// render renders the scene into an offscreen buffer and applies all passes afterwards.
+func (c *PostProcessChain) render(renderState *RenderState, camera Camera, drawFunc DrawTargetFunc) {
+	viewport := renderState.Viewport()
+	size := vmath.Vec2i{viewport.Width, viewport.Height}
+	if !c.prepare(size) {
+		drawFunc(renderState) // render without post-processing
+		return
+	}
+
+	c.scene.SetClearColor(engine.clearColor)
+	c.scene.Render(renderState, camera, drawFunc)
+
+	texelSize := vmath.Vec2f{1 / float32(size[0]), 1 / float32(size[1])}
+	sceneKey := c.scene.TextureKey()
+	input := sceneKey
+
+	for idx, pass := range c.passes {
+		pass.AddTextureBinding(PostProcessInputUniformName, input)
+		restore := replaceTextureBindings(pass, PostProcessSceneTextureKey, sceneKey)
+		if sProg, _ := renderState.shaders.resolve(pass.sProgKey); sProg != nil {
+			if _, ok := sProg.getUniformLocation(PostProcessTexelSizeUniformName); ok {
+				pass.Uniform2f(PostProcessTexelSizeUniformName, texelSize[0], texelSize[1])
+			}
+		}
+		c.quad.SetMaterial(pass)
+
+		if idx == len(c.passes)-1 { // last pass: render into the original framebuffer
+			passState := renderState.beginNested(c.camera, renderState.framebuffer, viewport)
+			c.quad.Draw(passState)
+			renderState.endNested(passState)
+			restore()
+			break
+		}
+
+		target := c.targets[idx%2]
+		target.Render(renderState, c.camera, c.quad.Draw)
+		restore()
+		input = target.TextureKey()
+	}
+}
+
+// replaceTextureBindings temporarily binds another texture to all uniforms that are bound to the given key.
+// The returned function restores the original bindings.
+func replaceTextureBindings(mat *Material, from, to TextureKey) (restore func()) {
+	var replaced []string
+	for name, key := range mat.textures {
+		if key == from {
+			mat.textures[name] = to
+			replaced = append(replaced, name)
+		}
+	}
+	return func() {
+		for _, name := range replaced {
+			mat.textures[name] = from
+		}
+	}
+}
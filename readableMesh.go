@@ -0,0 +1,93 @@
+package nora
+
+import (
+	"github.com/maja42/nora/assert"
+)
+
+// ReadableMesh is a mesh that keeps a copy of its geometry in CPU memory.
+// The geometry can be inspected for picking, collision detection or serialization,
+// at the cost of additional memory.
+type ReadableMesh struct {
+	Mesh
+	geometry Geometry
+}
+
+// NewReadableMesh creates a new readable mesh with the given material.
+func NewReadableMesh(mat *Material) *ReadableMesh {
+	return &ReadableMesh{
+		Mesh: *NewMesh(mat),
+	}
+}
+
+// Geometry returns the mesh's current geometry.
+// The caller must not modify the returned geometry; Copy() it instead.
+func (m *ReadableMesh) Geometry() *Geometry {
+	return &m.geometry
+}
+
+// SetVertexData is equivalent to Mesh.SetVertexData. The data is copied.
+func (m *ReadableMesh) SetVertexData(vertexCount int, vertices []float32, indices []uint16, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	m.SetGeometry(NewGeometry(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout))
+}
+
+// SetVertexData32 is equivalent to Mesh.SetVertexData32. The data is copied.
+func (m *ReadableMesh) SetVertexData32(vertexCount int, vertices []float32, indices []uint32, primitiveType PrimitiveType, vertexAttributes []string, bufferLayout BufferLayout) {
+	m.SetGeometry(NewGeometry32(vertexCount, vertices, indices, primitiveType, vertexAttributes, bufferLayout))
+}
+
+// SetRawVertexData is equivalent to Mesh.SetRawVertexData. The data is copied.
+func (m *ReadableMesh) SetRawVertexData(vertexCount int, vertices []byte, indices []uint32, primitiveType PrimitiveType, vertexFormat []VertexAttrib, bufferLayout BufferLayout) {
+	m.SetGeometry(NewRawGeometry(vertexCount, vertices, indices, primitiveType, vertexFormat, bufferLayout))
+}
+
+// SetGeometry is equivalent to Mesh.SetGeometry. The geometry is copied.
+// Unknown attribute components of float32 vertex data are taken from the material's shader.
+func (m *ReadableMesh) SetGeometry(geom *Geometry) {
+	m.Mesh.SetGeometry(geom)
+	m.geometry = *geom.Copy()
+	if m.geometry.components == nil && m.geometry.vertexFormat == nil && len(m.geometry.vertexAttributes) > 1 {
+		m.geometry.components = shaderAttributeComponents(m.material.sProgKey, m.geometry.vertexAttributes)
+	}
+}
+
+// SetVertexSubData is equivalent to Mesh.SetVertexSubData.
+func (m *ReadableMesh) SetVertexSubData(vertexOffset int, vertices []float32) {
+	m.Mesh.SetVertexSubData(vertexOffset, vertices)
+	if assert.True(m.geometry.vertexFormat == nil, "Mesh uses byte-level vertex data") {
+		copy(m.geometry.vertices[vertexOffset*m.vertexSize/4:], vertices)
+	}
+}
+
+// SetRawVertexSubData is equivalent to Mesh.SetRawVertexSubData.
+func (m *ReadableMesh) SetRawVertexSubData(vertexOffset int, vertices []byte) {
+	m.Mesh.SetRawVertexSubData(vertexOffset, vertices)
+	if assert.True(m.geometry.vertexFormat != nil, "Mesh uses float32 vertex data") {
+		copy(m.geometry.rawVertices[vertexOffset*m.vertexSize:], vertices)
+	}
+}
+
+// SetIndexSubData is equivalent to Mesh.SetIndexSubData.
+func (m *ReadableMesh) SetIndexSubData(indexOffset int, indices []uint16) {
+	m.Mesh.SetIndexSubData(indexOffset, indices)
+	if m.geometry.indices16 != nil {
+		copy(m.geometry.indices16[indexOffset:], indices)
+	} else if m.geometry.indices != nil {
+		for i, idx := range indices {
+			m.geometry.indices[indexOffset+i] = uint32(idx)
+		}
+	}
+}
+
+// SetIndexSubData32 is equivalent to Mesh.SetIndexSubData32.
+func (m *ReadableMesh) SetIndexSubData32(indexOffset int, indices []uint32) {
+	m.Mesh.SetIndexSubData32(indexOffset, indices)
+	if m.geometry.hasIndices() {
+		copy(m.geometry.wideIndices()[indexOffset:], indices)
+	}
+}
+
+// ClearVertexData is equivalent to Mesh.ClearVertexData.
+func (m *ReadableMesh) ClearVertexData() {
+	m.Mesh.ClearVertexData()
+	m.geometry = Geometry{}
+}
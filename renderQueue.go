@@ -0,0 +1,144 @@
+package nora
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/maja42/vmath"
+)
+
+// drawCommand is a deferred mesh draw call.
+type drawCommand struct {
+	mesh      *Mesh
+	material  *Material
+	transform vmath.Mat4f
+	depth     float32 // clip-space depth of the object's origin; only used for transparent materials
+}
+
+// renderQueue collects draw commands and issues them in an order that minimizes state changes.
+type renderQueue struct {
+	opaque      []drawCommand
+	transparent []drawCommand
+
+	materialKeys map[*Material]materialSortKey
+}
+
+// materialSortKey determines the draw order of opaque materials.
+type materialSortKey struct {
+	sProgKey ShaderProgKey
+	textures string // all texture bindings
+	order    int    // order in which the material was first queued
+}
+
+func (k materialSortKey) less(o materialSortKey) bool {
+	if k.sProgKey != o.sProgKey {
+		return k.sProgKey < o.sProgKey
+	}
+	if k.textures != o.textures {
+		return k.textures < o.textures
+	}
+	return k.order < o.order
+}
+
+// BeginQueue defers all subsequent mesh draw calls until EndQueue() is called.
+// Queued opaque meshes are sorted by shader, textures and material to reduce state changes,
+// transparent meshes (see Material.SetTransparent) are drawn afterwards, from back to front.
+// Meshes and materials must not be modified or destroyed while they are queued.
+// Changing the camera or viewport draws all queued meshes.
+func (r *RenderState) BeginQueue() {
+	if r.queue == nil {
+		r.queue = &renderQueue{}
+	}
+}
+
+// EndQueue draws all queued meshes and disables deferred drawing.
+func (r *RenderState) EndQueue() {
+	r.flushQueue()
+	r.queue = nil
+}
+
+// enqueue defers drawing the mesh with the current model transformation.
+func (q *renderQueue) enqueue(renderState *RenderState, mesh *Mesh) {
+	cmd := drawCommand{
+		mesh:      mesh,
+		material:  mesh.material,
+		transform: renderState.TransformStack.Top(),
+	}
+	if !cmd.material.transparent {
+		q.opaque = append(q.opaque, cmd)
+		return
+	}
+
+	vp, _ := renderState.camera.Matrix()
+	pos := vp.Mul(cmd.transform).MulVec(vmath.Vec4f{0, 0, 0, 1})
+	if pos[3] != 0 {
+		cmd.depth = pos[2] / pos[3]
+	}
+	q.transparent = append(q.transparent, cmd)
+}
+
+// flushQueue draws all queued meshes.
+func (r *RenderState) flushQueue() {
+	q := r.queue
+	if q == nil || len(q.opaque)+len(q.transparent) == 0 {
+		return
+	}
+	r.queue = nil // draw immediately
+
+	q.sort()
+	for _, cmd := range q.opaque {
+		q.draw(r, cmd)
+	}
+	for _, cmd := range q.transparent {
+		q.draw(r, cmd)
+	}
+
+	q.opaque = q.opaque[:0]
+	q.transparent = q.transparent[:0]
+	q.materialKeys = nil
+	r.queue = q
+}
+
+func (q *renderQueue) sort() {
+	q.materialKeys = make(map[*Material]materialSortKey)
+	for _, cmd := range q.opaque {
+		if _, ok := q.materialKeys[cmd.material]; !ok {
+			q.materialKeys[cmd.material] = materialSortKey{
+				sProgKey: cmd.material.sProgKey,
+				textures: textureSortKey(cmd.material),
+				order:    len(q.materialKeys),
+			}
+		}
+	}
+	sort.SliceStable(q.opaque, func(i, j int) bool {
+		return q.materialKeys[q.opaque[i].material].less(q.materialKeys[q.opaque[j].material])
+	})
+	// back to front
+	sort.SliceStable(q.transparent, func(i, j int) bool {
+		return q.transparent[i].depth > q.transparent[j].depth
+	})
+}
+
+func (q *renderQueue) draw(renderState *RenderState, cmd drawCommand) {
+	renderState.TransformStack.Push()
+	renderState.TransformStack.Set(cmd.transform)
+	cmd.mesh.draw(renderState, cmd.material)
+	renderState.TransformStack.Pop()
+}
+
+// textureSortKey returns a string that is equal for materials with the same texture bindings.
+func textureSortKey(material *Material) string {
+	bindings := make([]string, 0, len(material.textures))
+	for uniform, texKey := range material.textures {
+		bindings = append(bindings, uniform+"="+string(texKey))
+	}
+	sort.Strings(bindings)
+	return strings.Join(bindings, ";")
+}
+
+// flush draws all deferred geometry (queued meshes and pending batches).
+// Needs to be called before the camera, viewport or framebuffer changes.
+func (r *RenderState) flush() {
+	r.flushQueue()
+	r.flushBatch()
+}
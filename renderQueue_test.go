@@ -0,0 +1,111 @@
+package nora
+
+import (
+	"testing"
+)
+
+func TestRenderQueueSortOpaque(t *testing.T) {
+	texMat := func(sProgKey ShaderProgKey, texKey TextureKey) *Material {
+		m := NewMaterial(sProgKey)
+		m.AddTextureBinding("tex", texKey)
+		return m
+	}
+	a1 := texMat("a", "t1")
+	a2 := texMat("a", "t2")
+	a2b := texMat("a", "t2") // same state as a2, queued later
+	b := NewMaterial("b")
+	plainA := NewMaterial("a")
+
+	tests := []struct {
+		name      string
+		materials []*Material // queue order
+		want      []int       // expected draw order (indices into materials)
+	}{
+		{"empty", nil, nil},
+		{"single", []*Material{b}, []int{0}},
+		{"by shader", []*Material{b, a1, b, a1}, []int{1, 3, 0, 2}},
+		{"by textures", []*Material{a2, a1, a2, a1}, []int{1, 3, 0, 2}},
+		{"untextured first", []*Material{a1, plainA}, []int{1, 0}},
+		{"equal state keeps first-queued order", []*Material{a2b, a2, a2b, a2}, []int{0, 2, 1, 3}},
+		{"all criteria", []*Material{b, a2, a1, a2b, b, a1}, []int{2, 5, 1, 3, 0, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meshes := make([]*Mesh, len(tt.materials))
+			q := &renderQueue{}
+			for i, mat := range tt.materials {
+				meshes[i] = &Mesh{material: mat}
+				q.opaque = append(q.opaque, drawCommand{mesh: meshes[i], material: mat})
+			}
+			q.sort()
+
+			for i, cmd := range q.opaque {
+				if want := meshes[tt.want[i]]; cmd.mesh != want {
+					t.Errorf("draw %d: got mesh %d, want %d", i, indexOf(meshes, cmd.mesh), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderQueueSortTransparent(t *testing.T) {
+	tests := []struct {
+		name   string
+		depths []float32 // queue order
+		want   []int     // expected draw order
+	}{
+		{"back to front", []float32{0.1, 0.9, 0.5}, []int{1, 2, 0}},
+		{"negative depths", []float32{-0.5, 0, -1}, []int{1, 0, 2}},
+		{"equal depths keep queue order", []float32{0.3, 0.7, 0.3, 0.7}, []int{1, 3, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// transparent meshes must not be grouped by material
+			mats := []*Material{NewMaterial("a"), NewMaterial("b")}
+			meshes := make([]*Mesh, len(tt.depths))
+			q := &renderQueue{}
+			for i, depth := range tt.depths {
+				meshes[i] = &Mesh{}
+				q.transparent = append(q.transparent, drawCommand{mesh: meshes[i], material: mats[i%2], depth: depth})
+			}
+			q.sort()
+
+			for i, cmd := range q.transparent {
+				if want := meshes[tt.want[i]]; cmd.mesh != want {
+					t.Errorf("draw %d: got mesh %d, want %d", i, indexOf(meshes, cmd.mesh), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTextureSortKey(t *testing.T) {
+	m1 := NewMaterial("s")
+	m1.AddTextureBinding("a", "x")
+	m1.AddTextureBinding("b", "y")
+	m2 := NewMaterial("s")
+	m2.AddTextureBinding("b", "y")
+	m2.AddTextureBinding("a", "x")
+	m3 := NewMaterial("s")
+	m3.AddTextureBinding("a", "y")
+	m3.AddTextureBinding("b", "x")
+
+	if k1, k2 := textureSortKey(m1), textureSortKey(m2); k1 != k2 {
+		t.Errorf("equal bindings result in different keys %q and %q", k1, k2)
+	}
+	if k1, k3 := textureSortKey(m1), textureSortKey(m3); k1 == k3 {
+		t.Errorf("different bindings result in the same key %q", k1)
+	}
+	if k := textureSortKey(NewMaterial("s")); k != "" {
+		t.Errorf("material without textures has key %q", k)
+	}
+}
+
+func indexOf(meshes []*Mesh, mesh *Mesh) int {
+	for i, m := range meshes {
+		if m == mesh {
+			return i
+		}
+	}
+	return -1
+}
@@ -3,6 +3,7 @@ package nora
 import (
 	"github.com/maja42/gl"
 	"github.com/maja42/nora/assert"
+	"github.com/maja42/nora/color"
 	"github.com/maja42/vmath"
 )
 
@@ -11,26 +12,199 @@ type RenderState struct {
 	shaders        *ShaderStore
 	samplerManager *samplerManager
 
+	framebuffer gl.Framebuffer // render target; 0 = window
+	viewport    Viewport       // absolute framebuffer coordinates
+
+	cameraStack   []Camera   // previous cameras (PushCamera)
+	viewportStack []Viewport // previous viewports (PushViewport)
+
 	// state
-	material *Material // currently applied material
-	sProgID  sProgID   // currently used shader program
+	material     *Material // currently applied material
+	sProgID      sProgID   // currently used shader program
+	vpCamera     Camera    // camera of the uploaded view-projection matrix
+	vpDirtyCount int       // change-counter of the uploaded view-projection matrix
+	setsApplied  bool      // true if the uniform sets were applied to the current shader program
+	setsChanges  uint64    // uniform set modifications at the time they were applied
+
+	// deferred drawing
+	batch *Batch       // batch with pending geometry; flushed before the state changes
+	queue *renderQueue // deferred draw calls; nil if meshes are drawn immediately
 
 	TransformStack vmath.MatStack4f
 
 	// statistics
 	totalDrawCalls  int
 	totalPrimitives int
+	culledMeshes    int
 }
 
-func newRenderState(cam Camera, shaders *ShaderStore, samplerManager *samplerManager) *RenderState {
+func newRenderState(cam Camera, viewport Viewport, shaders *ShaderStore, samplerManager *samplerManager) *RenderState {
 	return &RenderState{
 		camera:         cam,
 		shaders:        shaders,
 		samplerManager: samplerManager,
+		viewport:       viewport,
 		TransformStack: *vmath.NewMatStack4f(),
 	}
 }
 
+// Viewport returns the area of the framebuffer that is rendered to.
+func (r *RenderState) Viewport() Viewport {
+	return r.viewport
+}
+
+// Camera returns the camera that is used for rendering.
+func (r *RenderState) Camera() Camera {
+	if flipped, ok := r.camera.(flippedCamera); ok {
+		return flipped.Camera
+	}
+	return r.camera
+}
+
+// PushCamera replaces the camera for all subsequent draw calls, until PopCamera() is called.
+// Can be used for rendering multiple views within a single frame.
+func (r *RenderState) PushCamera(cam Camera) {
+	r.flush()
+	r.cameraStack = append(r.cameraStack, r.camera)
+	if r.framebuffer.Value != 0 {
+		cam = flippedCamera{cam}
+	}
+	r.camera = cam
+}
+
+// PopCamera restores the camera that was used before the last PushCamera() call.
+func (r *RenderState) PopCamera() {
+	count := len(r.cameraStack)
+	if !assert.True(count > 0, "Camera stack: pop on empty stack") {
+		return
+	}
+	r.flush()
+	r.camera = r.cameraStack[count-1]
+	r.cameraStack = r.cameraStack[:count-1]
+}
+
+// PushViewport restricts all subsequent draw calls to a sub-area of the current viewport, until PopViewport() is called.
+// The given viewport is relative to the current one, with the origin in the bottom-left corner.
+// The camera's clip space [-1, +1] is mapped onto the new viewport.
+func (r *RenderState) PushViewport(viewport Viewport) {
+	r.flush()
+	abs := Viewport{
+		X:      r.viewport.X + viewport.X,
+		Y:      r.viewport.Y + viewport.Y,
+		Width:  viewport.Width,
+		Height: viewport.Height,
+	}
+	if r.framebuffer.Value != 0 { // render targets are rendered upside-down
+		abs.Y = r.viewport.Y + r.viewport.Height - viewport.Y - viewport.Height
+	}
+	r.viewportStack = append(r.viewportStack, r.viewport)
+	r.viewport = abs
+	gl.Viewport(abs.X, abs.Y, abs.Width, abs.Height)
+}
+
+// PopViewport restores the viewport that was used before the last PushViewport() call.
+func (r *RenderState) PopViewport() {
+	count := len(r.viewportStack)
+	if !assert.True(count > 0, "Viewport stack: pop on empty stack") {
+		return
+	}
+	r.flush()
+	r.viewport = r.viewportStack[count-1]
+	r.viewportStack = r.viewportStack[:count-1]
+	gl.Viewport(r.viewport.X, r.viewport.Y, r.viewport.Width, r.viewport.Height)
+}
+
+// PushView combines PushCamera() and PushViewport().
+// Used for split-screen, picture-in-picture or overlays. Must be finished with PopView().
+func (r *RenderState) PushView(cam Camera, viewport Viewport) {
+	r.PushCamera(cam)
+	r.PushViewport(viewport)
+}
+
+// PopView restores the camera and viewport that were used before the last PushView() call.
+func (r *RenderState) PopView() {
+	r.PopViewport()
+	r.PopCamera()
+}
+
+// ClearViewport clears the color and depth buffer within the current viewport.
+func (r *RenderState) ClearViewport(c color.Color) {
+	gl.ClearColor(c.R, c.G, c.B, c.A)
+	r.clearViewport(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	c = engine.clearColor
+	gl.ClearColor(c.R, c.G, c.B, c.A)
+}
+
+// ClearViewportDepth clears the depth buffer within the current viewport.
+// Used for overlays that should be drawn on top of everything else.
+func (r *RenderState) ClearViewportDepth() {
+	r.clearViewport(gl.DEPTH_BUFFER_BIT)
+}
+
+func (r *RenderState) clearViewport(mask gl.Enum) {
+	r.flush()
+	v := r.viewport
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(int32(v.X), int32(v.Y), int32(v.Width), int32(v.Height))
+	gl.Clear(mask)
+	gl.Disable(gl.SCISSOR_TEST)
+}
+
+// assertStacksEmpty ensures that all pushed states were popped again.
+func (r *RenderState) assertStacksEmpty(situation string) {
+	assert.True(r.TransformStack.Size() == 1, "Transform stack: not empty %s", situation)
+	assert.True(len(r.cameraStack) == 0, "Camera stack: not empty %s", situation)
+	assert.True(len(r.viewportStack) == 0, "Viewport stack: not empty %s", situation)
+	assert.True(r.queue == nil, "Render queue: not ended %s", situation)
+}
+
+// bindFramebuffer (re-)binds the render state's framebuffer and viewport.
+func (r *RenderState) bindFramebuffer() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.framebuffer)
+	gl.Viewport(r.viewport.X, r.viewport.Y, r.viewport.Width, r.viewport.Height)
+	if r.framebuffer.Value == 0 {
+		gl.FrontFace(gl.CCW)
+	} else {
+		gl.FrontFace(gl.CW) // render targets are rendered upside-down, which flips the winding order
+	}
+}
+
+// invalidate forgets the currently applied material and shader.
+// Needs to be called if the OpenGL state was modified by someone else (eg. a nested render state).
+func (r *RenderState) invalidate() {
+	r.material = nil
+	r.sProgID = sProgID{}
+	r.vpCamera = nil
+}
+
+// beginNested creates and binds a separate render state for rendering with a different camera or into a different framebuffer.
+// Must be finished with endNested().
+func (r *RenderState) beginNested(cam Camera, framebuffer gl.Framebuffer, viewport Viewport) *RenderState {
+	r.flush()
+	if framebuffer.Value != 0 {
+		cam = flippedCamera{cam}
+	}
+	nested := newRenderState(cam, viewport, r.shaders, r.samplerManager)
+	nested.framebuffer = framebuffer
+	nested.bindFramebuffer()
+	return nested
+}
+
+// endNested finishes rendering with a nested render state and restores the own state.
+// Statistics are added to the own render state.
+func (r *RenderState) endNested(nested *RenderState) {
+	nested.flush()
+	nested.assertStacksEmpty("after nested rendering")
+
+	r.totalDrawCalls += nested.totalDrawCalls
+	r.totalPrimitives += nested.totalPrimitives
+	r.culledMeshes += nested.culledMeshes
+
+	// the nested state used a different camera and modified the bound framebuffer
+	r.invalidate()
+	r.bindFramebuffer()
+}
+
 func (r *RenderState) applyMaterial(material *Material) *shaderProgram {
 	sProgKey := material.sProgKey
 	sProg := r.applyShader(sProgKey)
@@ -39,7 +213,7 @@ func (r *RenderState) applyMaterial(material *Material) *shaderProgram {
 	}
 
 	if r.material != material {
-		material.apply(sProg, r.samplerManager)
+		material.apply(sProg, r.sProgID, r.samplerManager)
 		r.material = material
 	}
 
@@ -52,22 +226,35 @@ func (r *RenderState) applyMaterial(material *Material) *shaderProgram {
 }
 
 func (r *RenderState) applyShader(sProgKey ShaderProgKey) *shaderProgram {
+	r.flushBatch()
 	sProg, sProgID := r.shaders.resolve(sProgKey)
 	if sProg == nil {
 		assert.Fail("shader %q is not loaded", sProgKey)
 		return nil
 	}
 
+	trans, dirtyCount := r.camera.Matrix()
 	if r.sProgID != sProgID {
 		sProg.Use()
+		r.sProgID = sProgID
+		r.vpCamera = nil
+		r.setsApplied = false
+	}
 
+	// upload the view-projection matrix if the shader or camera changed, or the camera was modified
+	if r.vpCamera != r.camera || r.vpDirtyCount != dirtyCount {
 		vpMatrix := sProg.vpMatrixLocation
 		if vpMatrix.Value >= 0 { // The shader supports view-projection transforms
-			trans, _ := r.camera.Matrix()
 			gl.UniformMatrix4fv(vpMatrix, trans[:])
 		}
-
-		r.sProgID = sProgID
+		r.vpCamera = r.camera
+		r.vpDirtyCount = dirtyCount
+	}
+	// upload the uniform sets if the shader changed or any set was modified
+	if changes := uniformSetChanges.Load(); !r.setsApplied || r.setsChanges != changes {
+		engine.uniformSets.apply(sProg)
+		r.setsApplied = true
+		r.setsChanges = changes
 	}
 	return sProg
 }
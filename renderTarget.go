@@ -0,0 +1,172 @@
+package nora
+
+import (
+	"fmt"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+	"github.com/sirupsen/logrus"
+)
+
+// DrawTargetFunc renders into a render target.
+type DrawTargetFunc func(renderState *RenderState)
+
+// RenderTarget is an offscreen framebuffer that renders into a texture.
+// The texture is registered in the TextureStore and can be bound to materials like any other texture.
+//
+// Like loaded textures, render targets have their origin in the top-left corner.
+// Materials can therefore sample them with the same texture coordinates and shaders.
+type RenderTarget struct {
+	texKey     TextureKey
+	properties TextureProperties
+	size       vmath.Vec2i
+	clearColor color.Color
+
+	owner *Engine // framebuffers are not shared between contexts
+	fbo   gl.Framebuffer
+	depth gl.Renderbuffer
+}
+
+// NewRenderTarget creates a new render target with the given size.
+// The resulting texture is registered with the given key.
+// Mipmap filters are not supported. Needs to be destroyed afterwards to free GPU resources.
+// The render target can only be rendered by the engine whose context is current (ie. within its frame function, or the most recently created engine).
+func NewRenderTarget(key TextureKey, size vmath.Vec2i, properties TextureProperties) (*RenderTarget, error) {
+	if size[0] <= 0 || size[1] <= 0 {
+		return nil, fmt.Errorf("invalid render target size %v", size)
+	}
+
+	tex, err := engine.Textures.register(key, size, properties)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &RenderTarget{
+		texKey:     key,
+		properties: properties,
+		size:       size,
+		clearColor: color.Transparent,
+		owner:      engine,
+		fbo:        gl.CreateFramebuffer(),
+		depth:      gl.CreateRenderbuffer(),
+	}
+	logrus.Infof("Creating render target %q (%dx%d)", key, size[0], size[1])
+
+	if err := t.attach(tex); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Destroy deletes the framebuffer and unloads the texture.
+func (t *RenderTarget) Destroy() {
+	if !t.isCurrent() {
+		return
+	}
+	engine.Textures.Unload(t.texKey)
+	gl.DeleteRenderbuffer(t.depth)
+	gl.DeleteFramebuffer(t.fbo)
+}
+
+// TextureKey returns the key of the texture that is rendered into.
+func (t *RenderTarget) TextureKey() TextureKey {
+	return t.texKey
+}
+
+// Size returns the render target's size in pixels.
+func (t *RenderTarget) Size() vmath.Vec2i {
+	return t.size
+}
+
+// ClearColor returns the color that is used for clearing the render target before rendering.
+func (t *RenderTarget) ClearColor() color.Color {
+	return t.clearColor
+}
+
+// SetClearColor changes the color that is used for clearing the render target before rendering.
+func (t *RenderTarget) SetClearColor(c color.Color) {
+	t.clearColor = c
+}
+
+// Resize changes the size of the render target.
+// The texture is replaced by a new texture object. Materials referring to the texture key automatically use the new one.
+// The content of the render target is lost.
+func (t *RenderTarget) Resize(size vmath.Vec2i) error {
+	if size[0] <= 0 || size[1] <= 0 {
+		return fmt.Errorf("invalid render target size %v", size)
+	}
+	if size == t.size {
+		return nil
+	}
+	if !t.isCurrent() {
+		return fmt.Errorf("render target %q belongs to a different engine", t.texKey)
+	}
+
+	tex, err := engine.Textures.resize(t.texKey, size, t.properties)
+	if err != nil {
+		return err
+	}
+	t.size = size
+	return t.attach(tex)
+}
+
+// isCurrent checks if the render target belongs to the engine with the current context.
+func (t *RenderTarget) isCurrent() bool {
+	return assert.True(engine == t.owner, "Render target %q belongs to a different engine", t.texKey)
+}
+
+// attach connects the framebuffer with the given texture and (re-)allocates the depth buffer.
+func (t *RenderTarget) attach(tex *texture) error {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, gl.Framebuffer{})
+
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.tex, 0)
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT16, t.size[0], t.size[1])
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, t.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, gl.Renderbuffer{})
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("render target %q is incomplete (status 0x%x)", t.texKey, status)
+	}
+	assert.NoGLError("attach render target %q", t.texKey)
+	return nil
+}
+
+// Render clears the render target and renders into it using the given camera.
+// Must be called during sync. rendering (within the frame function).
+// The passed render state is the one of the current frame; draw calls are added to its statistics.
+// Rendering into a texture that is sampled at the same time leads to undefined results.
+func (t *RenderTarget) Render(renderState *RenderState, camera Camera, drawFunc DrawTargetFunc) {
+	if !t.isCurrent() {
+		return
+	}
+	targetState := renderState.beginNested(camera, t.fbo, Viewport{0, 0, t.size[0], t.size[1]})
+
+	c := t.clearColor
+	gl.ClearColor(c.R, c.G, c.B, c.A)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	c = engine.clearColor
+	gl.ClearColor(c.R, c.G, c.B, c.A)
+
+	drawFunc(targetState)
+	renderState.endNested(targetState)
+}
+
+// flippedCamera mirrors the y-axis of the underlying camera.
+// Used for rendering into textures, so that they have their origin in the top-left corner.
+type flippedCamera struct {
+	Camera
+}
+
+// Matrix returns the y-flipped view-projection matrix and its change-counter.
+func (c flippedCamera) Matrix() (vmath.Mat4f, int) {
+	m, dirtyCount := c.Camera.Matrix()
+	// negate the second row (column-major)
+	m[1], m[5], m[9], m[13] = -m[1], -m[5], -m[9], -m[13]
+	return m, dirtyCount
+}
@@ -67,19 +67,21 @@ func (t *samplerManager) bind(samplerLoc gl.Uniform, textureKey TextureKey) {
 		}
 
 		t.activeBindings[sampler] = true
-		t.texBinding[textureKey] = texBinding{
+		binding = texBinding{
 			texture: texID,
 			sampler: sampler,
 		}
+		t.texBinding[textureKey] = binding
 
 		logrus.Debugf("Binding texture %q (%s) to samplerManager %d", textureKey, texture, sampler)
 
 		gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + sampler))
 		gl.BindTexture(gl.TEXTURE_2D, texture.tex)
-	} else if binding.texture != texID { // The texture behind the textureKey was reloaded
+	} else if binding.texture != texID { // The texture behind the textureKey was reloaded or resized
 		gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + binding.sampler))
 		gl.BindTexture(gl.TEXTURE_2D, texture.tex)
 		binding.texture = texID
+		t.texBinding[textureKey] = binding
 	}
 	gl.Uniform1i(samplerLoc, binding.sampler)
 }
@@ -0,0 +1,91 @@
+package nora
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+)
+
+// ReadPixels reads a rectangular area of the currently bound framebuffer.
+// The rectangle is given in framebuffer coordinates, with the origin in the top-left corner.
+// Must be called during sync. rendering (within the frame function), or after RenderFrames() returned.
+// If there are multiple engines, reading outside of the frame function requires that no other engine rendered in the meantime.
+// Areas outside the framebuffer are undefined.
+func (n *Engine) ReadPixels(rect image.Rectangle) *image.RGBA {
+	rect = rect.Canon()
+	img := image.NewRGBA(rect)
+	width, height := rect.Dx(), rect.Dy()
+	if width == 0 || height == 0 {
+		return img
+	}
+
+	// OpenGL has its origin in the bottom-left corner
+	_, fbHeight := n.window.GetFramebufferSize()
+	glY := fbHeight - rect.Max.Y
+
+	pixels := make([]byte, width*height*4)
+	gl.ReadPixels(pixels, rect.Min.X, glY, width, height, gl.RGBA, gl.UNSIGNED_BYTE)
+	assert.NoGLError("Read pixels")
+
+	// flip vertically, so that the first row is the top-most one
+	stride := width * 4
+	for row := 0; row < height; row++ {
+		src := pixels[(height-1-row)*stride : (height-row)*stride]
+		copy(img.Pix[row*img.Stride:], src)
+	}
+	return img
+}
+
+// Screenshot reads the content of the whole framebuffer.
+// Must be called during sync. rendering (within the frame function), or after RenderFrames() returned.
+// Use CaptureFrame() to take screenshots from other go-routines.
+func (n *Engine) Screenshot() *image.RGBA {
+	width, height := n.window.GetFramebufferSize()
+	return n.ReadPixels(image.Rect(0, 0, width, height))
+}
+
+// CaptureFrame requests a screenshot of the next rendered frame.
+// The image is taken after the frame function returned, right before the buffers are swapped.
+// If the engine is destroyed before the next frame is rendered, the channel is closed without sending an image.
+// Can be called from any go-routine. Calling it from within the frame function and waiting for the result leads to a deadlock.
+func (n *Engine) CaptureFrame() <-chan *image.RGBA {
+	c := make(chan *image.RGBA, 1)
+	n.captureLock.Lock()
+	defer n.captureLock.Unlock()
+	n.captureRequests = append(n.captureRequests, c)
+	return c
+}
+
+// fulfillCaptureRequests takes a screenshot if requested and sends it to all waiting callers.
+// Must be called during sync. rendering.
+func (n *Engine) fulfillCaptureRequests() {
+	n.captureLock.Lock()
+	requests := n.captureRequests
+	n.captureRequests = nil
+	n.captureLock.Unlock()
+
+	if len(requests) == 0 {
+		return
+	}
+	img := n.Screenshot()
+	for _, c := range requests {
+		c <- img
+	}
+}
+
+// SavePNG writes the given image into a png file.
+func SavePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("encode png: %w", err)
+	}
+	return file.Close()
+}
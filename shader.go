@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"github.com/maja42/gl"
 	"github.com/maja42/nora/assert"
@@ -33,8 +34,23 @@ type shaderProgram struct {
 	attributeLocations     map[string]gl.Attrib
 	attributeTypes         map[string]gl.Enum // stores the underlying type of the vertex attributes
 	uniformLocations       map[string]gl.Uniform
+	uniformTypes           map[string]activeUniform
 	modelTransformLocation gl.Uniform
 	vpMatrixLocation       gl.Uniform
+
+	// shared uniforms (see UniformSet)
+	uniformSets map[string]uploadedUniformSet // uniform sets that were uploaded to the program
+}
+
+// activeUniform describes a uniform of a shader program.
+type activeUniform struct {
+	typ  gl.Enum
+	size int // number of array elements; 1 for non-array uniforms
+}
+
+type uploadedUniformSet struct {
+	set        *UniformSet
+	dirtyCount int
 }
 
 // newShaderProgram creates a new shader program on the GPU.
@@ -160,12 +176,17 @@ func (p *shaderProgram) fetchVertexAttributes() {
 func (p *shaderProgram) fetchUniforms() {
 	uniformCount := uint32(gl.GetProgrami(p.program, gl.ACTIVE_UNIFORMS))
 	p.uniformLocations = make(map[string]gl.Uniform, uniformCount)
+	p.uniformTypes = make(map[string]activeUniform, uniformCount)
 	p.modelTransformLocation.Value = -1
 	p.vpMatrixLocation.Value = -1
+	p.uniformSets = make(map[string]uploadedUniformSet)
 
 	for idx := uint32(0); idx < uniformCount; idx++ {
-		name, _, _ := gl.GetActiveUniform(p.program, idx) // we *could* store the type for validation purposes
-		location := gl.Uniform{Value: int32(idx)}
+		name, size, typ := gl.GetActiveUniform(p.program, idx)
+		location := gl.GetUniformLocation(p.program, name)
+		if location.Value < 0 { // built-in uniform (eg. gl_DepthRange)
+			continue
+		}
 
 		switch name {
 		case ModelTransformUniformName:
@@ -173,7 +194,12 @@ func (p *shaderProgram) fetchUniforms() {
 		case VPMatrixUniformName:
 			p.vpMatrixLocation = location
 		default:
+			// arrays are reported as "name[0]"; they can be accessed with and without index
+			name = strings.TrimSuffix(name, "[0]")
 			p.uniformLocations[name] = location
+			p.uniformLocations[name+"[0]"] = location
+			p.uniformTypes[name] = activeUniform{typ, size}
+			p.uniformTypes[name+"[0]"] = activeUniform{typ, size}
 		}
 	}
 }
@@ -182,22 +208,20 @@ func (p *shaderProgram) Use() {
 	gl.UseProgram(p.program)
 }
 
-// configureVertexAttributes ensures that all given vertex attributes are either enabled or disabled
-// configureVertexAttributes must only be called during sync. rendering (no context lock)
-func (p *shaderProgram) configureVertexAttributes(vertexAttributes []string, enable bool) {
-	// If the enabling/disabling of vertex attributes would result in a performance loss,
-	// it would be possible to cache the "currently enabled attributes" in the renderState,
-	// use an uint64 bitset to check which attributes need to be disabled/enabled and go from there.
-
-	for _, name := range vertexAttributes {
-		loc, ok := p.attributeLocations[name]
-		iAssertTrue(ok, "Enable vertex attribute %q: not supported by %s", name, p) // cannot happen (already caught when configuring a mesh with vertices/materials)
-		if enable {
-			gl.EnableVertexAttribArray(loc)
-		} else {
-			gl.DisableVertexAttribArray(loc)
+// uploadUniformSet sets the members of a uniform set as regular uniforms, if they changed since the last upload.
+// This is the per-program fallback for uniform blocks, which are not available without OpenGL ES 3.
+// The program must be in use. Members that are not used by the program are ignored.
+func (p *shaderProgram) uploadUniformSet(set *UniformSet) {
+	if uploaded := p.uniformSets[set.name]; uploaded.set == set && uploaded.dirtyCount == set.dirtyCount {
+		return
+	}
+	for i := range set.members {
+		member := &set.members[i]
+		if loc, ok := p.uniformLocations[member.name]; ok {
+			member.upload(loc)
 		}
 	}
+	p.uniformSets[set.name] = uploadedUniformSet{set, set.dirtyCount}
 }
 
 func (p *shaderProgram) getAttribLocation(attributeName string) (gl.Attrib, gl.Enum) {
@@ -50,8 +50,8 @@ type ShaderStore struct {
 }
 
 // newShaderStore creates a new, empty store for shader programs.
-func newShaderStore() ShaderStore {
-	return ShaderStore{
+func newShaderStore() *ShaderStore {
+	return &ShaderStore{
 		shaderPrograms: make(map[ShaderProgKey]loadedShader),
 		fsWatcher:      hotreload.NewWatcher(),
 	}
@@ -74,7 +74,12 @@ func (t *texture) Load(path string, properties TextureProperties) error {
 	if err != nil {
 		return fmt.Errorf("decode texture file %q: %v", path, err)
 	}
+	logrus.Debugf("Texture format of %s is %q", t, format)
+	return t.LoadImage(img, properties)
+}
 
+// LoadImage uploads the given image into the texture.
+func (t *texture) LoadImage(img image.Image, properties TextureProperties) error {
 	// The image.Image interface does not provide access to the raw texel data of the texture.
 	// TODO: Check if "img" is a well-known type (RGB, RGBA, Alpha, ...) and avoid the copy + format conversion
 	// 		 If the type is not well-known, either don't support it, access the "Pix"-field via reflection (if available), or make a to-RGBA copy.
@@ -86,7 +91,7 @@ func (t *texture) Load(path string, properties TextureProperties) error {
 		return fmt.Errorf("texture bounds of %s don't start at zero", t)
 	}
 
-	logrus.Debugf("Texture format of %s is %q. Size: %dx%d", t, format, bounds.Max.X, bounds.Max.Y)
+	logrus.Debugf("Texture size of %s: %dx%d", t, bounds.Max.X, bounds.Max.Y)
 
 	draw.Draw(rgba, bounds, img, image.Point{0, 0}, draw.Src) // Copy / convert image data
 
@@ -108,6 +113,25 @@ func (t *texture) Load(path string, properties TextureProperties) error {
 	return nil
 }
 
+// Allocate reserves GPU memory for an empty texture with the given size.
+// Used for render targets. The content of the texture is undefined.
+func (t *texture) Allocate(size vmath.Vec2i, properties TextureProperties) {
+	logrus.Debugf("Allocating %s with size %dx%d...", t, size[0], size[1])
+
+	t.size = size.Vec2f()
+
+	gl.BindTexture(gl.TEXTURE_2D, t.tex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, size[0], size[1], gl.RGBA, gl.UNSIGNED_BYTE, nil)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, int(properties.MagFilter))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, int(properties.MinFilter))
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, int(properties.WrapS))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, int(properties.WrapT))
+
+	assert.NoGLError("allocate %s", t)
+}
+
 func (t *texture) Destroy() {
 	logrus.Debugf("Destroying %s", t)
 	gl.DeleteTexture(t.tex)
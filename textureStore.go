@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
 	"path/filepath"
 	"sync"
 
@@ -24,7 +25,7 @@ type loadedTexture struct {
 	// for texture hot-reloading:
 	//forbidReload        bool // if true, this texture must not be reloaded, because other resources depend on it/refer to it
 	intermediateTexture *texture
-	definition          *TextureDefinition
+	definition          *TextureDefinition // nil for textures that are not loaded from the filesystem (eg. render targets)
 }
 
 // texID uniquely identifies a loaded texture
@@ -53,8 +54,8 @@ type TextureStore struct {
 }
 
 // newTextureStore creates a new, empty store for textures.
-func newTextureStore() TextureStore {
-	return TextureStore{
+func newTextureStore() *TextureStore {
+	return &TextureStore{
 		textures:  make(map[TextureKey]loadedTexture),
 		fsWatcher: hotreload.NewWatcher(),
 	}
@@ -96,6 +97,9 @@ func (s *TextureStore) Load(key TextureKey, def *TextureDefinition) (vmath.Vec2f
 	def.Path = filepath.Clean(def.Path)
 
 	if loadedTexture, ok := s.textures[key]; ok {
+		if loadedTexture.definition == nil {
+			return vmath.Vec2f{}, fmt.Errorf("texture %q is not loaded from the filesystem and cannot be replaced", key)
+		}
 		//if loadedTexture.forbidReload {
 		//	return fmt.Errorf("texture %q is already loaded and cannot be replaced", key)
 		//}
@@ -136,6 +140,9 @@ func (s *TextureStore) reloadTexture(key TextureKey) (vmath.Vec2f, error) {
 	if !ok {
 		return vmath.Vec2f{}, fmt.Errorf("texture %q is not loaded", key)
 	}
+	if loadedTexture.definition == nil {
+		return vmath.Vec2f{}, fmt.Errorf("texture %q is not loaded from the filesystem and cannot be reloaded", key)
+	}
 	if loadedTexture.intermediateTexture == nil {
 		loadedTexture.intermediateTexture = newTexture()
 	}
@@ -152,6 +159,72 @@ func (s *TextureStore) reloadTexture(key TextureKey) (vmath.Vec2f, error) {
 	return loadedTexture.texture.Size(), nil
 }
 
+// LoadImage loads a texture from an in-memory image, like images embedded in model files.
+// The texture can't be hot-reloaded.
+func (s *TextureStore) LoadImage(key TextureKey, img image.Image, properties TextureProperties) (vmath.Vec2f, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if _, ok := s.textures[key]; ok {
+		return vmath.Vec2f{}, fmt.Errorf("texture %q is already loaded", key)
+	}
+
+	tex := newTexture()
+	if err := tex.LoadImage(img, properties); err != nil {
+		tex.Destroy()
+		return vmath.Vec2f{}, err
+	}
+	s.textures[key] = loadedTexture{
+		id:      newTexID(),
+		texture: tex,
+	}
+	return tex.size, nil
+}
+
+// register adds a new, empty texture that is not loaded from the filesystem.
+// Used for render targets.
+func (s *TextureStore) register(key TextureKey, size vmath.Vec2i, properties TextureProperties) (*texture, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if _, ok := s.textures[key]; ok {
+		return nil, fmt.Errorf("texture %q is already loaded", key)
+	}
+
+	tex := newTexture()
+	tex.Allocate(size, properties)
+
+	s.textures[key] = loadedTexture{
+		id:      newTexID(),
+		texture: tex,
+	}
+	return tex, nil
+}
+
+// resize replaces a registered texture with a new, empty texture of the given size.
+// The texture's generation is incremented, causing samplers to re-bind the new texture object.
+func (s *TextureStore) resize(key TextureKey, size vmath.Vec2i, properties TextureProperties) (*texture, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	loadedTexture, ok := s.textures[key]
+	if !ok {
+		return nil, fmt.Errorf("texture %q is not loaded", key)
+	}
+	if loadedTexture.definition != nil {
+		return nil, fmt.Errorf("texture %q is not a render target", key)
+	}
+	if loadedTexture.intermediateTexture == nil {
+		loadedTexture.intermediateTexture = newTexture()
+	}
+	loadedTexture.intermediateTexture.Allocate(size, properties)
+
+	loadedTexture.texture, loadedTexture.intermediateTexture = loadedTexture.intermediateTexture, loadedTexture.texture
+	loadedTexture.id.generation = loadedTexture.id.generation + 1
+	s.textures[key] = loadedTexture
+	return loadedTexture.texture, nil
+}
+
 // UnloadAll unloads all textures
 func (s *TextureStore) UnloadAll() {
 	s.m.Lock()
@@ -175,8 +248,10 @@ func (s *TextureStore) unload(key TextureKey) {
 		return
 	}
 
-	err := s.fsWatcher.Remove(loadedTexture.definition.Path, key)
-	iAssertTrue(err == nil, "Failed to un-watch texture: %s", err)
+	if loadedTexture.definition != nil {
+		err := s.fsWatcher.Remove(loadedTexture.definition.Path, key)
+		iAssertTrue(err == nil, "Failed to un-watch texture: %s", err)
+	}
 
 	loadedTexture.texture.Destroy()
 	if loadedTexture.intermediateTexture != nil {
@@ -198,7 +273,11 @@ func (s *TextureStore) resolve(key TextureKey) (texID, *texture) {
 }
 
 // Definition returns the texture definition with the given key.
-// If the texture is not loaded, an empty definition is returned.
+// If the texture is not loaded or is a render target, an empty definition is returned.
 func (s *TextureStore) Definition(key TextureKey) TextureDefinition {
-	return *s.textures[key].definition
+	def := s.textures[key].definition
+	if def == nil {
+		return TextureDefinition{}
+	}
+	return *def
 }
@@ -31,6 +31,9 @@ import (
 //
 //  Note that the resulting world->model transform can also be cached, but this is in the responsibility
 //  of the models.
+//  Alternatively, models can be attached to a "Node". Nodes form an explicit hierarchy and draw/update
+//  their attached components. Their cached world transformation is only used for queries (WorldTransform);
+//  drawing still uses the matrix stack.
 //
 
 // Transform manages a transformation matrix that can be positioned
@@ -46,6 +49,7 @@ type Transform struct {
 	transformDirty bool
 	inverse        vmath.Mat4f
 	inverseDirty   bool
+	dirtyCount     int // incremented every time the transformation matrix changes
 }
 
 // NewTransform creates a new identity-transformation.
@@ -67,6 +71,7 @@ func (t *Transform) ClearTransform() {
 	t.transformDirty = false
 	t.inverse = vmath.Ident4f()
 	t.inverseDirty = false
+	t.dirtyCount++
 }
 
 // SetPosition sets the 3D object position.
@@ -257,6 +262,7 @@ func (t *Transform) GetTransform() vmath.Mat4f {
 	if t.transformDirty {
 		t.transform = vmath.Mat4fFromRotationTranslationScaleOrigin(t.rotation, t.position, t.scaling, t.origin)
 		t.transformDirty = false
+		t.dirtyCount++
 	}
 	return t.transform
 }
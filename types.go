@@ -9,11 +9,27 @@ import (
 type PrimitiveType gl.Enum
 type BufferLayout uint8
 
+// BufferUsage is a hint on how often the vertex data of a mesh is modified.
+type BufferUsage gl.Enum
+
+// Viewport defines a rectangular area of a framebuffer in pixels.
+// The origin is in the bottom-left corner.
+type Viewport struct {
+	X, Y          int
+	Width, Height int
+}
+
 const (
 	InterleavedBuffer BufferLayout = iota // eg. <pos, rgb> <pos, rgb> ...
 	CompactBuffer                         // eg. <pos, pos> <rgb, rgb>
 )
 
+const (
+	StaticBuffer  BufferUsage = gl.STATIC_DRAW  // set once, drawn many times
+	DynamicBuffer BufferUsage = gl.DYNAMIC_DRAW // modified repeatedly; sub-data changes are uploaded once before drawing
+	StreamBuffer  BufferUsage = gl.STREAM_DRAW  // replaced (nearly) every frame; the buffer is orphaned on every upload
+)
+
 func (p PrimitiveType) String() string {
 	switch p {
 	case gl.POINTS:
@@ -34,6 +50,18 @@ func (p PrimitiveType) String() string {
 	return fmt.Sprintf("PrimitiveType(0x%x)", gl.Enum(p))
 }
 
+// AspectRatio returns the viewport's width divided by its height.
+func (v Viewport) AspectRatio() float32 {
+	if v.Height == 0 {
+		return 0
+	}
+	return float32(v.Width) / float32(v.Height)
+}
+
+func (v Viewport) String() string {
+	return fmt.Sprintf("Viewport(%d, %d, %d x %d)", v.X, v.Y, v.Width, v.Height)
+}
+
 func (b BufferLayout) String() string {
 	switch b {
 	case InterleavedBuffer:
@@ -43,3 +71,15 @@ func (b BufferLayout) String() string {
 	}
 	return fmt.Sprintf("BufferLayout(%d)", int(b))
 }
+
+func (u BufferUsage) String() string {
+	switch u {
+	case StaticBuffer:
+		return "StaticBuffer"
+	case DynamicBuffer:
+		return "DynamicBuffer"
+	case StreamBuffer:
+		return "StreamBuffer"
+	}
+	return fmt.Sprintf("BufferUsage(0x%x)", gl.Enum(u))
+}
@@ -0,0 +1,139 @@
+package nora
+
+import (
+	"fmt"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+)
+
+// uniformValue is the value of a uniform or uniform array.
+type uniformValue struct {
+	typ    gl.Enum   // element type: FLOAT, FLOAT_VEC2-4, INT, INT_VEC2-4 or FLOAT_MAT2-4
+	floats []float32 // values of float and matrix types
+	ints   []int32   // values of int types
+}
+
+// uniformComponents contains the number of components of a single element of the supported uniform value types.
+var uniformComponents = map[gl.Enum]int{
+	gl.FLOAT:      1,
+	gl.FLOAT_VEC2: 2,
+	gl.FLOAT_VEC3: 3,
+	gl.FLOAT_VEC4: 4,
+	gl.INT:        1,
+	gl.INT_VEC2:   2,
+	gl.INT_VEC3:   3,
+	gl.INT_VEC4:   4,
+	gl.FLOAT_MAT2: 4,
+	gl.FLOAT_MAT3: 9,
+	gl.FLOAT_MAT4: 16,
+}
+
+// uniformTypeNames contains the GLSL names of uniform types.
+var uniformTypeNames = map[gl.Enum]string{
+	gl.FLOAT:        "float",
+	gl.FLOAT_VEC2:   "vec2",
+	gl.FLOAT_VEC3:   "vec3",
+	gl.FLOAT_VEC4:   "vec4",
+	gl.INT:          "int",
+	gl.INT_VEC2:     "ivec2",
+	gl.INT_VEC3:     "ivec3",
+	gl.INT_VEC4:     "ivec4",
+	gl.BOOL:         "bool",
+	gl.BOOL_VEC2:    "bvec2",
+	gl.BOOL_VEC3:    "bvec3",
+	gl.BOOL_VEC4:    "bvec4",
+	gl.FLOAT_MAT2:   "mat2",
+	gl.FLOAT_MAT3:   "mat3",
+	gl.FLOAT_MAT4:   "mat4",
+	gl.SAMPLER_2D:   "sampler2D",
+	gl.SAMPLER_CUBE: "samplerCube",
+}
+
+func uniformTypeName(typ gl.Enum) string {
+	if name, ok := uniformTypeNames[typ]; ok {
+		return name
+	}
+	return fmt.Sprintf("type 0x%x", uint32(typ))
+}
+
+// newFloatUniform creates a float, vector or matrix uniform value.
+// Multiple elements form an array.
+func newFloatUniform(typ gl.Enum, values []float32) (uniformValue, bool) {
+	comps := uniformComponents[typ]
+	ok := assert.True(len(values) > 0 && len(values)%comps == 0, "Invalid %s uniform value: %d floats", uniformTypeName(typ), len(values))
+	return uniformValue{typ: typ, floats: values}, ok
+}
+
+// newIntUniform creates an int or int vector uniform value.
+// Multiple elements form an array.
+func newIntUniform(typ gl.Enum, values []int32) (uniformValue, bool) {
+	comps := uniformComponents[typ]
+	ok := assert.True(len(values) > 0 && len(values)%comps == 0, "Invalid %s uniform value: %d ints", uniformTypeName(typ), len(values))
+	return uniformValue{typ: typ, ints: values}, ok
+}
+
+// elements returns the number of array elements.
+func (u *uniformValue) elements() int {
+	comps := uniformComponents[u.typ]
+	if comps == 0 {
+		return 0
+	}
+	if u.ints != nil {
+		return len(u.ints) / comps
+	}
+	return len(u.floats) / comps
+}
+
+// upload sets the value of a uniform of the currently used shader program.
+func (u *uniformValue) upload(loc gl.Uniform) {
+	switch u.typ {
+	case gl.FLOAT:
+		gl.Uniform1fv(loc, u.floats)
+	case gl.FLOAT_VEC2:
+		gl.Uniform2fv(loc, u.floats)
+	case gl.FLOAT_VEC3:
+		gl.Uniform3fv(loc, u.floats)
+	case gl.FLOAT_VEC4:
+		gl.Uniform4fv(loc, u.floats)
+	case gl.INT:
+		gl.Uniform1iv(loc, u.ints)
+	case gl.INT_VEC2:
+		gl.Uniform2iv(loc, u.ints)
+	case gl.INT_VEC3:
+		gl.Uniform3iv(loc, u.ints)
+	case gl.INT_VEC4:
+		gl.Uniform4iv(loc, u.ints)
+	case gl.FLOAT_MAT2:
+		gl.UniformMatrix2fv(loc, u.floats)
+	case gl.FLOAT_MAT3:
+		gl.UniformMatrix3fv(loc, u.floats)
+	case gl.FLOAT_MAT4:
+		gl.UniformMatrix4fv(loc, u.floats)
+	default:
+		iAssertFail("Unknown uniform type %s", uniformTypeName(u.typ))
+	}
+}
+
+// uniformAccepts returns true if a shader uniform of the given type can be set with values of the given element type.
+// Booleans can be set with ints or floats, samplers with ints (texture units).
+func uniformAccepts(shaderType, valueType gl.Enum) bool {
+	switch shaderType {
+	case gl.BOOL:
+		return valueType == gl.INT || valueType == gl.FLOAT
+	case gl.BOOL_VEC2:
+		return valueType == gl.INT_VEC2 || valueType == gl.FLOAT_VEC2
+	case gl.BOOL_VEC3:
+		return valueType == gl.INT_VEC3 || valueType == gl.FLOAT_VEC3
+	case gl.BOOL_VEC4:
+		return valueType == gl.INT_VEC4 || valueType == gl.FLOAT_VEC4
+	case gl.SAMPLER_2D, gl.SAMPLER_CUBE:
+		return valueType == gl.INT
+	}
+	return shaderType == valueType
+}
+
+// isSamplerType returns true if the uniform type is a texture sampler.
+func isSamplerType(typ gl.Enum) bool {
+	return typ == gl.SAMPLER_2D || typ == gl.SAMPLER_CUBE
+}
@@ -0,0 +1,233 @@
+package nora
+
+import (
+	"time"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/assert"
+	"github.com/maja42/nora/color"
+	"github.com/maja42/vmath"
+	"go.uber.org/atomic"
+)
+
+// GlobalUniformsName is the name of the uniform set containing the engine-provided global uniforms.
+// Shaders declare the members they need as regular uniforms, like this:
+//
+//	uniform float time;
+//	uniform vec2 resolution;
+const GlobalUniformsName = "Globals"
+
+// Names of the engine-provided global uniforms.
+// Values are updated once at the beginning of every frame.
+const (
+	TimeUniformName            = "time"            // float; seconds since the engine was created
+	FrameUniformName           = "frame"           // int; frame number
+	ResolutionUniformName      = "resolution"      // vec2; size of the engine's viewport in pixels
+	MousePosUniformName        = "mousePos"        // vec2; cursor position in clip space
+	InverseVPMatrixUniformName = "inverseVPMatrix" // mat4; inverse view-projection matrix of the engine's camera
+)
+
+// UniformSet is a named group of uniforms that is shared by all shader programs.
+// Shaders declare the members as regular uniforms. Values are uploaded to each program that uses them,
+// once after they changed, instead of being applied by every material.
+//
+// Shaders that don't use the set, or only parts of it, are not affected.
+//
+// Uniform blocks (UBOs) are not supported, because they require OpenGL ES 3 / WebGL2,
+// which is not available with the gl bindings. Every program therefore receives its own copy of the values.
+type UniformSet struct {
+	name       string
+	members    []uniformSetMember
+	lookup     map[string]int
+	dirtyCount int // incremented every time a value changes
+}
+
+type uniformSetMember struct {
+	uniformValue
+	name string
+}
+
+// NewUniformSet creates a new, empty uniform set.
+// The set needs to be added to the engine before it can be used by shaders.
+func NewUniformSet(name string) *UniformSet {
+	return &UniformSet{
+		name:   name,
+		lookup: make(map[string]int),
+	}
+}
+
+// Name returns the set's name.
+func (s *UniformSet) Name() string {
+	return s.name
+}
+
+func (s *UniformSet) Uniform1f(uniformName string, x float32) {
+	s.setFloats(uniformName, gl.FLOAT, []float32{x})
+}
+
+func (s *UniformSet) Uniform2f(uniformName string, x, y float32) {
+	s.setFloats(uniformName, gl.FLOAT_VEC2, []float32{x, y})
+}
+
+func (s *UniformSet) Uniform3f(uniformName string, x, y, z float32) {
+	s.setFloats(uniformName, gl.FLOAT_VEC3, []float32{x, y, z})
+}
+
+func (s *UniformSet) Uniform4f(uniformName string, x, y, z, w float32) {
+	s.setFloats(uniformName, gl.FLOAT_VEC4, []float32{x, y, z, w})
+}
+
+func (s *UniformSet) Uniform4fColor(uniformName string, c color.Color) {
+	s.setFloats(uniformName, gl.FLOAT_VEC4, []float32{c.R, c.G, c.B, c.A})
+}
+
+func (s *UniformSet) Uniform1i(uniformName string, x int32) {
+	s.setInts(uniformName, gl.INT, []int32{x})
+}
+
+func (s *UniformSet) Uniform2i(uniformName string, x, y int32) {
+	s.setInts(uniformName, gl.INT_VEC2, []int32{x, y})
+}
+
+func (s *UniformSet) Uniform3i(uniformName string, x, y, z int32) {
+	s.setInts(uniformName, gl.INT_VEC3, []int32{x, y, z})
+}
+
+func (s *UniformSet) Uniform4i(uniformName string, x, y, z, w int32) {
+	s.setInts(uniformName, gl.INT_VEC4, []int32{x, y, z, w})
+}
+
+func (s *UniformSet) UniformMatrix2fv(uniformName string, v []float32) {
+	if assert.True(len(v) == 4, "Invalid mat2 (%d values)", len(v)) {
+		s.setFloats(uniformName, gl.FLOAT_MAT2, v)
+	}
+}
+
+func (s *UniformSet) UniformMatrix3fv(uniformName string, v []float32) {
+	if assert.True(len(v) == 9, "Invalid mat3 (%d values)", len(v)) {
+		s.setFloats(uniformName, gl.FLOAT_MAT3, v)
+	}
+}
+
+func (s *UniformSet) UniformMatrix4fv(uniformName string, v []float32) {
+	if assert.True(len(v) == 16, "Invalid mat4 (%d values)", len(v)) {
+		s.setFloats(uniformName, gl.FLOAT_MAT4, v)
+	}
+}
+
+func (s *UniformSet) setFloats(name string, typ gl.Enum, values []float32) {
+	member := s.member(name, typ)
+	if member == nil {
+		return
+	}
+	member.floats = append(member.floats[:0], values...)
+	s.dirtyCount++
+	uniformSetChanges.Inc()
+}
+
+func (s *UniformSet) setInts(name string, typ gl.Enum, values []int32) {
+	member := s.member(name, typ)
+	if member == nil {
+		return
+	}
+	member.ints = append(member.ints[:0], values...)
+	s.dirtyCount++
+	uniformSetChanges.Inc()
+}
+
+// member returns the member with the given name and type. New members are appended to the set.
+func (s *UniformSet) member(name string, typ gl.Enum) *uniformSetMember {
+	if idx, ok := s.lookup[name]; ok {
+		member := &s.members[idx]
+		if !assert.True(member.typ == typ, "Uniform %q of set %q: type can't be changed", name, s.name) {
+			return nil
+		}
+		return member
+	}
+	s.lookup[name] = len(s.members)
+	s.members = append(s.members, uniformSetMember{
+		uniformValue: uniformValue{typ: typ},
+		name:         name,
+	})
+	return &s.members[len(s.members)-1]
+}
+
+// uniformSetChanges is incremented every time a uniform set is modified, added or removed.
+// Allows skipping the uniform sets while drawing with the same shader program, as long as nothing changed.
+var uniformSetChanges atomic.Uint64
+
+// uniformSets contains all uniform sets that are used by the engine's shader programs.
+type uniformSets struct {
+	sets []*UniformSet
+}
+
+// add registers a set.
+func (u *uniformSets) add(set *UniformSet) {
+	for _, s := range u.sets {
+		if !assert.True(s.name != set.name, "Uniform set %q was already added", set.name) {
+			return
+		}
+	}
+	u.sets = append(u.sets, set)
+	uniformSetChanges.Inc()
+}
+
+func (u *uniformSets) remove(set *UniformSet) {
+	for i, s := range u.sets {
+		if s == set {
+			u.sets = append(u.sets[:i], u.sets[i+1:]...)
+			uniformSetChanges.Inc()
+			return
+		}
+	}
+}
+
+// apply uploads modified uniform sets and ensures that they are used by the given shader program.
+func (u *uniformSets) apply(p *shaderProgram) {
+	for _, set := range u.sets {
+		p.uploadUniformSet(set)
+	}
+}
+
+// newGlobalUniforms creates the uniform set with the engine-provided global uniforms.
+func newGlobalUniforms() *UniformSet {
+	set := NewUniformSet(GlobalUniformsName)
+	set.Uniform1f(TimeUniformName, 0)
+	set.Uniform1i(FrameUniformName, 0)
+	set.Uniform2f(ResolutionUniformName, 0, 0)
+	set.Uniform2f(MousePosUniformName, 0, 0)
+	ident := vmath.Ident4f()
+	set.UniformMatrix4fv(InverseVPMatrixUniformName, ident[:])
+	return set
+}
+
+// updateGlobalUniforms sets the global uniforms for the current frame.
+func (n *Engine) updateGlobalUniforms(frame uint64) {
+	set := n.globalUniforms
+	set.Uniform1f(TimeUniformName, float32(time.Since(n.created).Seconds()))
+	set.Uniform1i(FrameUniformName, int32(frame))
+	set.Uniform2f(ResolutionUniformName, float32(n.viewport.Width), float32(n.viewport.Height))
+	mousePos := n.InteractionSystem.MousePosClipSpace()
+	set.Uniform2f(MousePosUniformName, mousePos[0], mousePos[1])
+	vp, _ := n.Camera.Matrix()
+	inverse, _ := vp.Inverse()
+	set.UniformMatrix4fv(InverseVPMatrixUniformName, inverse[:])
+}
+
+// GlobalUniforms returns the uniform set with the engine-provided global uniforms (see GlobalUniformsName).
+// The values are overwritten at the beginning of every frame.
+func (n *Engine) GlobalUniforms() *UniformSet {
+	return n.globalUniforms
+}
+
+// AddUniformSet makes a uniform set available to all shader programs.
+// The set's name must be unique.
+func (n *Engine) AddUniformSet(set *UniformSet) {
+	n.uniformSets.add(set)
+}
+
+// RemoveUniformSet removes a uniform set that was added before.
+// The set is not destroyed.
+func (n *Engine) RemoveUniformSet(set *UniformSet) {
+	n.uniformSets.remove(set)
+}
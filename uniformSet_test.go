@@ -0,0 +1,132 @@
+package nora
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maja42/gl"
+)
+
+func TestUniformSetMembers(t *testing.T) {
+	tests := []struct {
+		name       string
+		set        func(s *UniformSet)
+		members    []string  // in declaration order
+		types      []gl.Enum // of all members
+		dirtyCount int
+	}{
+		{
+			name:       "declaration order",
+			set:        func(s *UniformSet) { s.Uniform1f("b", 1); s.Uniform2i("a", 1, 2); s.Uniform4f("c", 1, 2, 3, 4) },
+			members:    []string{"b", "a", "c"},
+			types:      []gl.Enum{gl.FLOAT, gl.INT_VEC2, gl.FLOAT_VEC4},
+			dirtyCount: 3,
+		},
+		{
+			name:       "updates keep the member",
+			set:        func(s *UniformSet) { s.Uniform1f("a", 1); s.Uniform1f("a", 2) },
+			members:    []string{"a"},
+			types:      []gl.Enum{gl.FLOAT},
+			dirtyCount: 2,
+		},
+		{
+			name:       "type can't be changed",
+			set:        func(s *UniformSet) { s.Uniform1f("a", 1); s.Uniform1i("a", 2) },
+			members:    []string{"a"},
+			types:      []gl.Enum{gl.FLOAT},
+			dirtyCount: 1,
+		},
+		{
+			name:       "invalid matrix",
+			set:        func(s *UniformSet) { s.UniformMatrix4fv("m", make([]float32, 9)) },
+			dirtyCount: 0,
+		},
+		{
+			name: "matrices",
+			set: func(s *UniformSet) {
+				s.UniformMatrix2fv("m2", make([]float32, 4))
+				s.UniformMatrix3fv("m3", make([]float32, 9))
+				s.UniformMatrix4fv("m4", make([]float32, 16))
+			},
+			members:    []string{"m2", "m3", "m4"},
+			types:      []gl.Enum{gl.FLOAT_MAT2, gl.FLOAT_MAT3, gl.FLOAT_MAT4},
+			dirtyCount: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewUniformSet("set")
+			tt.set(s)
+
+			var members []string
+			var types []gl.Enum
+			for _, m := range s.members {
+				members = append(members, m.name)
+				types = append(types, m.typ)
+			}
+			if !reflect.DeepEqual(members, tt.members) {
+				t.Errorf("got members %v, want %v", members, tt.members)
+			}
+			if !reflect.DeepEqual(types, tt.types) {
+				t.Errorf("got types %v, want %v", types, tt.types)
+			}
+			if s.dirtyCount != tt.dirtyCount {
+				t.Errorf("dirty count is %d, want %d", s.dirtyCount, tt.dirtyCount)
+			}
+		})
+	}
+}
+
+func TestUniformSetValues(t *testing.T) {
+	s := NewUniformSet("set")
+	s.Uniform3f("f", 1, 2, 3)
+	s.Uniform3f("f", 4, 5, 6)
+	s.Uniform4i("i", 1, 2, 3, 4)
+
+	if f := s.members[s.lookup["f"]].floats; !reflect.DeepEqual(f, []float32{4, 5, 6}) {
+		t.Errorf("got floats %v, want [4 5 6]", f)
+	}
+	if i := s.members[s.lookup["i"]].ints; !reflect.DeepEqual(i, []int32{1, 2, 3, 4}) {
+		t.Errorf("got ints %v, want [1 2 3 4]", i)
+	}
+}
+
+func TestUniformSetsAdd(t *testing.T) {
+	var u uniformSets
+	a, b := NewUniformSet("a"), NewUniformSet("b")
+	u.add(a)
+	u.add(b)
+	u.add(NewUniformSet("a")) // duplicate name
+
+	if len(u.sets) != 2 || u.sets[0] != a || u.sets[1] != b {
+		t.Fatalf("got %d sets, want [a b]", len(u.sets))
+	}
+	u.remove(a)
+	if len(u.sets) != 1 || u.sets[0] != b {
+		t.Errorf("got %d sets after removal, want [b]", len(u.sets))
+	}
+}
+
+func TestUniformSetChanges(t *testing.T) {
+	var u uniformSets
+	s := NewUniformSet("set")
+
+	steps := []struct {
+		name    string
+		fn      func()
+		changed bool
+	}{
+		{"add", func() { u.add(s) }, true},
+		{"set value", func() { s.Uniform1f("a", 1) }, true},
+		{"invalid type", func() { s.Uniform1i("a", 1) }, false},
+		{"remove", func() { u.remove(s) }, true},
+		{"remove unknown set", func() { u.remove(NewUniformSet("other")) }, false},
+	}
+	for _, step := range steps {
+		before := uniformSetChanges.Load()
+		step.fn()
+		if changed := uniformSetChanges.Load() != before; changed != step.changed {
+			t.Errorf("%s: changed is %v, want %v", step.name, changed, step.changed)
+		}
+	}
+}
@@ -1,16 +1,265 @@
 package nora
 
-import "github.com/maja42/gl"
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/maja42/gl"
+	"github.com/maja42/nora/color"
+)
 
 type vaTypeProperties struct {
 	components uint8
 	compType   gl.Enum
 }
 
+// vaTypePropertyMapping maps the vertex attribute types used within shaders to their components.
 var vaTypePropertyMapping = map[gl.Enum]vaTypeProperties{
 	gl.FLOAT:      {1, gl.FLOAT},
 	gl.FLOAT_VEC2: {2, gl.FLOAT},
 	gl.FLOAT_VEC3: {3, gl.FLOAT},
 	gl.FLOAT_VEC4: {4, gl.FLOAT},
-	// Other vertex attribute types (int, ...) are currently not supported
+	// Integer attribute types (int, ivec, uint, uvec) are not supported, because they require glVertexAttribIPointer.
+	// Integer vertex data can still be read by float attributes (optionally normalized).
+}
+
+// HalfFloat is the component type of half-precision floats (GL_HALF_FLOAT), which is not declared by the gl package.
+// Half-float vertex attributes require OpenGL 3.0, OpenGL ES 3.0 or WebGL 2.
+const HalfFloat = gl.Enum(0x140B)
+
+// componentSizes contains the size in bytes of the supported vertex attribute component types.
+var componentSizes = map[gl.Enum]int{
+	gl.BYTE:           1,
+	gl.UNSIGNED_BYTE:  1,
+	gl.SHORT:          2,
+	gl.UNSIGNED_SHORT: 2,
+	gl.INT:            4,
+	gl.UNSIGNED_INT:   4,
+	HalfFloat:         2,
+	gl.FLOAT:          4,
+}
+
+// VertexAttrib describes how a vertex attribute is stored within byte-level vertex data.
+// Allows using smaller data types than float32, like normalized bytes for colors.
+//
+// Integer data is always read by float shader inputs (float, vec2, ...).
+// Integer shader inputs (int, ivec, uint, uvec) are not supported, because glVertexAttribIPointer
+// requires OpenGL ES 3 or WebGL 2 and is not provided by the gl package.
+type VertexAttrib struct {
+	Name       string
+	Components int     // 1 - 4
+	Type       gl.Enum // Component type: BYTE, UNSIGNED_BYTE, SHORT, UNSIGNED_SHORT, INT, UNSIGNED_INT, HalfFloat or FLOAT
+	// Normalized maps integer types to [0, 1] (unsigned) or [-1, 1] (signed).
+	// Non-normalized integers are converted to floats without scaling.
+	Normalized bool
+}
+
+// FloatAttrib creates a vertex attribute with float32 components.
+func FloatAttrib(name string, components int) VertexAttrib {
+	return VertexAttrib{name, components, gl.FLOAT, false}
+}
+
+// HalfFloatAttrib creates a vertex attribute with float16 components.
+func HalfFloatAttrib(name string, components int) VertexAttrib {
+	return VertexAttrib{name, components, HalfFloat, false}
+}
+
+// NormalizedUint8Attrib creates a vertex attribute with unsigned byte components that are mapped to [0, 1].
+// Suited for colors (see VertexData.Color).
+func NormalizedUint8Attrib(name string, components int) VertexAttrib {
+	return VertexAttrib{name, components, gl.UNSIGNED_BYTE, true}
+}
+
+// Size returns the size of a single attribute value in bytes.
+func (a VertexAttrib) Size() int {
+	return a.Components * componentSizes[a.Type]
+}
+
+func (a VertexAttrib) String() string {
+	norm := ""
+	if a.Normalized {
+		norm = ", normalized"
+	}
+	return fmt.Sprintf("%s(%d x 0x%x%s)", a.Name, a.Components, a.Type, norm)
+}
+
+// VertexSize returns the size of a single vertex in bytes.
+func VertexSize(format []VertexAttrib) int {
+	size := 0
+	for _, attr := range format {
+		size += attr.Size()
+	}
+	return size
+}
+
+// vertexAttributeNames returns the (ordered) names of all vertex attributes.
+func vertexAttributeNames(format []VertexAttrib) []string {
+	names := make([]string, len(format))
+	for i, attr := range format {
+		names[i] = attr.Name
+	}
+	return names
+}
+
+func equalVertexFormat(a, b []VertexAttrib) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// VertexData is a helper for creating byte-level vertex data.
+// Values are appended in the GPU's (little endian) byte order.
+type VertexData []byte
+
+// Float32 appends float32 values.
+func (d *VertexData) Float32(values ...float32) *VertexData {
+	for _, v := range values {
+		*d = appendUint32(*d, math.Float32bits(v))
+	}
+	return d
+}
+
+// Float16 appends values as half-precision floats.
+func (d *VertexData) Float16(values ...float32) *VertexData {
+	for _, v := range values {
+		*d = appendUint16(*d, float16bits(v))
+	}
+	return d
+}
+
+// Int8 appends int8 values.
+func (d *VertexData) Int8(values ...int8) *VertexData {
+	for _, v := range values {
+		*d = append(*d, byte(v))
+	}
+	return d
+}
+
+// Uint8 appends uint8 values.
+func (d *VertexData) Uint8(values ...uint8) *VertexData {
+	*d = append(*d, values...)
+	return d
+}
+
+// Int16 appends int16 values.
+func (d *VertexData) Int16(values ...int16) *VertexData {
+	for _, v := range values {
+		*d = appendUint16(*d, uint16(v))
+	}
+	return d
+}
+
+// Uint16 appends uint16 values.
+func (d *VertexData) Uint16(values ...uint16) *VertexData {
+	for _, v := range values {
+		*d = appendUint16(*d, v)
+	}
+	return d
+}
+
+// Int32 appends int32 values.
+func (d *VertexData) Int32(values ...int32) *VertexData {
+	for _, v := range values {
+		*d = appendUint32(*d, uint32(v))
+	}
+	return d
+}
+
+// Uint32 appends uint32 values.
+func (d *VertexData) Uint32(values ...uint32) *VertexData {
+	for _, v := range values {
+		*d = appendUint32(*d, v)
+	}
+	return d
+}
+
+// Color appends the color as four unsigned bytes (RGBA).
+// Needs to be read by a normalized UNSIGNED_BYTE vertex attribute.
+func (d *VertexData) Color(c color.Color) *VertexData {
+	return d.Uint8(normalizedUint8(c.R), normalizedUint8(c.G), normalizedUint8(c.B), normalizedUint8(c.A))
+}
+
+func normalizedUint8(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// float16bits returns the IEEE 754 half-precision representation of f, rounded to nearest even.
+// Values that are too big are converted to infinity, values that are too small are flushed to zero.
+func float16bits(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xFF) - 127 + 15
+	mantissa := bits & 0x7FFFFF
+
+	switch {
+	case bits&0x7FFFFFFF > 0x7F800000: // NaN
+		return sign | 0x7E00
+	case exp >= 0x1F: // overflow or infinity
+		return sign | 0x7C00
+	case exp <= 0: // subnormal or zero
+		if exp < -10 { // less than half of the smallest subnormal
+			return sign
+		}
+		mantissa |= 0x800000 // implicit leading bit
+		return sign | uint16(roundShift(mantissa, uint(14-exp)))
+	}
+	// a carry out of the mantissa increments the exponent, and results in infinity for the largest values
+	return sign | uint16(uint32(exp)<<10+roundShift(mantissa, 13))
+}
+
+// roundShift shifts v to the right, rounding to nearest even.
+func roundShift(v uint32, shift uint) uint32 {
+	result := v >> shift
+	remainder := v & (1<<shift - 1)
+	half := uint32(1) << (shift - 1)
+	if remainder > half || (remainder == half && result&1 == 1) {
+		result++
+	}
+	return result
+}
+
+// float16frombits converts an IEEE 754 half-precision value into a float32.
+func float16frombits(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1F
+	mantissa := uint32(h & 0x3FF)
+
+	switch {
+	case exp == 0x1F: // infinity or NaN
+		return math.Float32frombits(sign | 0x7F800000 | mantissa<<13)
+	case exp == 0 && mantissa == 0: // zero
+		return math.Float32frombits(sign)
+	case exp == 0: // subnormal
+		value := float32(mantissa) / (1 << 24)
+		if sign != 0 {
+			return -value
+		}
+		return value
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mantissa<<13)
 }
@@ -0,0 +1,88 @@
+package nora
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16bits(t *testing.T) {
+	tests := []struct {
+		name string
+		f    float32
+		h    uint16
+	}{
+		{"zero", 0, 0x0000},
+		{"negative zero", float32(math.Copysign(0, -1)), 0x8000},
+		{"one", 1, 0x3C00},
+		{"minus two", -2, 0xC000},
+		{"one third", 1.0 / 3, 0x3555},
+		{"largest normal", 65504, 0x7BFF},
+		{"rounds to largest normal", 65519, 0x7BFF},
+		{"rounds to infinity", 65520, 0x7C00},
+		{"overflow", 1e10, 0x7C00},
+		{"infinity", float32(math.Inf(1)), 0x7C00},
+		{"negative infinity", float32(math.Inf(-1)), 0xFC00},
+		{"NaN", float32(math.NaN()), 0x7E00},
+		{"smallest normal", 1.0 / (1 << 14), 0x0400},
+		{"smallest subnormal", 1.0 / (1 << 24), 0x0001},
+		{"half of smallest subnormal (tie to even)", 1.0 / (1 << 25), 0x0000},
+		{"above half of smallest subnormal", 1.0/(1<<25) + 1.0/(1<<30), 0x0001},
+		{"underflow", 1e-10, 0x0000},
+		{"subnormal rounds up to normal", 1.0/(1<<14) - 1.0/(1<<26), 0x0400},
+
+		// 1 + 2^-11 is exactly between 1 and the next half-float (1 + 2^-10)
+		{"tie rounds down to even", 1 + 1.0/(1<<11), 0x3C00},
+		{"above tie rounds up", 1 + 1.0/(1<<11) + 1.0/(1<<20), 0x3C01},
+		// 1 + 3*2^-11 is exactly between 1 + 2^-10 (odd) and 1 + 2^-9 (even)
+		{"tie rounds up to even", 1 + 3.0/(1<<11), 0x3C02},
+		{"truncation would round down", 1 + 1.9/(1<<10), 0x3C02},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if h := float16bits(tt.f); h != tt.h {
+				t.Errorf("float16bits(%g) = 0x%04x, want 0x%04x", tt.f, h, tt.h)
+			}
+		})
+	}
+}
+
+func TestFloat16frombits(t *testing.T) {
+	tests := []struct {
+		h uint16
+		f float32
+	}{
+		{0x0000, 0},
+		{0x3C00, 1},
+		{0xC000, -2},
+		{0x7BFF, 65504},
+		{0x0400, 1.0 / (1 << 14)},
+		{0x0001, 1.0 / (1 << 24)},
+		{0x8001, -1.0 / (1 << 24)},
+		{0x03FF, 1023.0 / (1 << 24)},
+		{0x7C00, float32(math.Inf(1))},
+		{0xFC00, float32(math.Inf(-1))},
+	}
+	for _, tt := range tests {
+		if f := float16frombits(tt.h); f != tt.f {
+			t.Errorf("float16frombits(0x%04x) = %g, want %g", tt.h, f, tt.f)
+		}
+	}
+	if f := float16frombits(0x7E00); !math.IsNaN(float64(f)) {
+		t.Errorf("float16frombits(0x7e00) = %g, want NaN", f)
+	}
+	if f := float16frombits(0x8000); f != 0 || !math.Signbit(float64(f)) {
+		t.Errorf("float16frombits(0x8000) = %g, want -0", f)
+	}
+}
+
+func TestFloat16RoundTrip(t *testing.T) {
+	// all finite half-floats survive the conversion to float32 and back
+	for h := 0; h <= 0xFFFF; h++ {
+		if h&0x7C00 == 0x7C00 { // infinity, NaN
+			continue
+		}
+		if got := float16bits(float16frombits(uint16(h))); got != uint16(h) {
+			t.Fatalf("round trip of 0x%04x resulted in 0x%04x", h, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package nora
+
+import (
+	"github.com/maja42/gl"
+)
+
+// vertexLayout contains the vertex attribute pointers of a mesh for a specific shader program.
+// Attribute locations, types, strides and offsets are determined once instead of on every draw call.
+// The layout is recalculated if the shader program is (re-)loaded or the mesh's vertex layout changes.
+//
+// Vertex array objects require OpenGL ES 3 or WebGL 2 and are not provided by the gl package.
+// The cached attribute pointers are therefore still applied before every draw call (the WebGL 1 path).
+type vertexLayout struct {
+	sProgID       sProgID
+	layoutVersion int             // the mesh's layout at the time the attribute pointers were calculated
+	vertex        []attribPointer // attributes within the vertex buffer
+}
+
+// attribPointer defines how a single attribute location reads from a vertex buffer.
+type attribPointer struct {
+	loc        gl.Attrib
+	components int
+	compType   gl.Enum
+	normalized bool
+	stride     int
+	offset     int
+}
+
+// vertexLayout returns the mesh's attribute pointers for the given shader program.
+// They are recalculated if needed.
+func (m *Mesh) vertexLayout(sProg *shaderProgram, sProgID sProgID) *vertexLayout {
+	if l := m.layout; l != nil && l.sProgID == sProgID && l.layoutVersion == m.layoutVersion {
+		return l
+	}
+	l := &vertexLayout{
+		sProgID:       sProgID,
+		layoutVersion: m.layoutVersion,
+		vertex:        m.vertexAttribPointers(sProg),
+	}
+	m.layout = l
+	return l
+}
+
+// enableAttribPointers enables the vertex attributes and defines their layout within the bound vertex buffer.
+func enableAttribPointers(pointers []attribPointer) {
+	for _, p := range pointers {
+		gl.EnableVertexAttribArray(p.loc)
+		gl.VertexAttribPointer(p.loc, p.components, p.compType, p.normalized, p.stride, p.offset)
+	}
+}
+
+// disableAttribPointers disables the vertex attributes.
+func disableAttribPointers(pointers []attribPointer) {
+	for _, p := range pointers {
+		gl.DisableVertexAttribArray(p.loc)
+	}
+}